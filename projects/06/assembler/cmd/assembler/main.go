@@ -1,69 +1,56 @@
 package main
 
 import (
-	"assembler/pkg/code"
-	"assembler/pkg/parser"
-	"assembler/pkg/symtable"
+	"assembler/pkg/assembler"
+	"assembler/pkg/romfile"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 )
 
 func main() {
-	filePath := os.Args[1]
+	writeListing := flag.Bool("listing", false, "also write a <name>.lst listing file")
+	writeSymbols := flag.Bool("symbols", false, "also write a <name>.sym symbol file")
+	formatFlag := flag.String("format", "hack", "output format: hack, bin (raw big-endian), ihex (Intel HEX), memb or memh (Verilog $readmemb/$readmemh)")
+	flag.Parse()
+	filePath := flag.Arg(0)
 
-	baseFilePath := filepath.Base(filePath)
-	fileName, _, _ := strings.Cut(baseFilePath, ".")
-	outputFile := fileName + ".hack"
-	f, err := os.Create(outputFile)
+	format, err := romfile.ParseFormat(*formatFlag)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer f.Close()
-
-	p := parser.New(filePath)
-	st := symtable.New()
 
-	currInstructionIndex := 0
-	for p.HasMoreLines() {
-		p.Advance()
-
-		if p.InstructionType() == parser.LInstruction {
-			st.AddEntry(p.Symbol(), currInstructionIndex)
-		} else {
-			currInstructionIndex++
-		}
+	program, err := assembler.AssembleFile(filePath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 
-	nextVariableIndex := 16
-	p = parser.New(filePath)
-	for p.HasMoreLines() {
-		p.Advance()
-
-		var binary string
-		switch p.InstructionType() {
-		case parser.AInstruction:
-			symbol := p.Symbol()
-			num, err := strconv.Atoi(symbol)
-			if err != nil {
-				if !st.Contains(symbol) {
-					st.AddEntry(symbol, nextVariableIndex)
-					nextVariableIndex++
-				}
-				num = st.GetAddress(symbol)
-			} 
-			binary = fmt.Sprintf("%016v", strconv.FormatInt(int64(num), 2))
-		case parser.CInstruction:
-			binary = "111" + code.Comp(p.Comp()) + code.Dest(p.Dest()) + code.Jump(p.Jump())
-		case parser.LInstruction:
-			continue
+	baseFilePath := filepath.Base(filePath)
+	fileName, _, _ := strings.Cut(baseFilePath, ".")
+	writeFile(fileName+format.Ext(), func(f *os.File) {
+		if err := romfile.Write(f, program.Words(), format); err != nil {
+			log.Fatal(err)
 		}
+	})
+	if *writeListing {
+		writeFile(fileName+".lst", func(f *os.File) { program.WriteListing(f) })
+	}
+	if *writeSymbols {
+		writeFile(fileName+".sym", func(f *os.File) { program.WriteSymbols(f) })
+	}
+}
 
-		f.WriteString(binary + "\n")
+func writeFile(outputFile string, write func(f *os.File)) {
+	f, err := os.Create(outputFile)
+	if err != nil {
+		log.Fatal(err)
 	}
+	defer f.Close()
 
+	write(f)
 	f.Sync()
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"assembler/pkg/assembler"
+	"assembler/pkg/disasm"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+	labels := flag.Bool("labels", false, "synthesise labels for jump targets")
+	symbols := flag.Bool("symbols", false, "use R0-R15 for addresses accessed through M, and SCREEN and KBD also where A is copied out")
+	verify := flag.Bool("verify", false, "check that the output assembles back into the input")
+	outputFile := flag.String("o", "", "write the assembly to this file instead of stdout")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		log.Fatal("usage: disasm [-labels] [-symbols] [-verify] [-o file.asm] file.hack")
+	}
+
+	filePath := flag.Arg(0)
+	input, err := os.ReadFile(filePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	program, err := disasm.Disassemble(strings.NewReader(string(input)), disasm.Options{Labels: *labels, Symbols: *symbols})
+	if err != nil {
+		log.Fatalf("%s: %v", filePath, err)
+	}
+	output := strings.Join(program.Lines, "\n") + "\n"
+
+	if *outputFile == "" {
+		fmt.Print(output)
+	} else if err := os.WriteFile(*outputFile, []byte(output), 0644); err != nil {
+		log.Fatal(err)
+	}
+
+	hasFailed := false
+	for _, addr := range program.Undefined {
+		fmt.Fprintf(os.Stderr, "%s: ROM[%d]: undefined instruction\n", filePath, addr)
+		hasFailed = true
+	}
+	if *verify && !hasFailed {
+		if err := verifyRoundTrip(output, string(input)); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", filePath, err)
+			hasFailed = true
+		}
+	}
+
+	if hasFailed {
+		os.Exit(1)
+	}
+}
+
+func verifyRoundTrip(output, input string) error {
+	program, err := assembler.Assemble(strings.NewReader(output), assembler.Options{Filename: "Verify.asm"})
+	if err != nil {
+		return err
+	}
+
+	expected := strings.Fields(input)
+	if len(program.Instructions) != len(expected) {
+		return fmt.Errorf("round trip produced %d instructions, expected %d", len(program.Instructions), len(expected))
+	}
+	for i, instruction := range program.Instructions {
+		if instruction.Binary() != expected[i] {
+			return fmt.Errorf("round trip mismatch at ROM[%d]: %s != %s", i, instruction.Binary(), expected[i])
+		}
+	}
+	return nil
+}
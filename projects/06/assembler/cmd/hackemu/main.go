@@ -0,0 +1,124 @@
+package main
+
+import (
+	"assembler/pkg/hackcpu"
+	"assembler/pkg/romfile"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	cycles := flag.Int("cycles", 100000, "number of clock cycles to run")
+	ramFlag := flag.String("ram", "0-15", "comma separated RAM addresses or ranges to dump, e.g. 0,1,256-260")
+	setFlag := flag.String("set", "", "comma separated RAM initialisations, e.g. 0=3,1=5")
+	formatFlag := flag.String("format", "", "program format: hack, bin, ihex, memb or memh (default: from the file extension)")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		log.Fatal("usage: hackemu [-cycles n] [-ram addresses] [-set addr=value,...] [-format f] file.hack")
+	}
+
+	format, err := romfile.FormatOf(flag.Arg(0))
+	if *formatFlag != "" {
+		format, err = romfile.ParseFormat(*formatFlag)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	cpu := hackcpu.New()
+	if err := cpu.Load(f, format); err != nil {
+		log.Fatalf("%s: %v", flag.Arg(0), err)
+	}
+
+	assignments, err := parseAssignments(*setFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, assignment := range assignments {
+		cpu.SetRAM(assignment.addr, assignment.value)
+	}
+
+	addrs, err := parseAddresses(*ramFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := cpu.Run(*cycles); err != nil {
+		log.Fatalf("cycle %d: %v", cpu.Cycles(), err)
+	}
+
+	fmt.Printf("PC=%d A=%d D=%d cycles=%d\n", cpu.PC(), cpu.A(), cpu.D(), cpu.Cycles())
+	for _, addr := range addrs {
+		fmt.Printf("RAM[%d] = %d\n", addr, cpu.RAM(addr))
+	}
+}
+
+type assignment struct {
+	addr  int
+	value int16
+}
+
+func parseAssignments(value string) ([]assignment, error) {
+	var assignments []assignment
+	if value == "" {
+		return assignments, nil
+	}
+	for _, part := range strings.Split(value, ",") {
+		addrStr, valueStr, found := strings.Cut(part, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid RAM assignment %q", part)
+		}
+		addr, err := parseAddress(addrStr)
+		if err != nil {
+			return nil, err
+		}
+		v, err := strconv.ParseInt(strings.TrimSpace(valueStr), 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RAM value %q", valueStr)
+		}
+		assignments = append(assignments, assignment{addr: addr, value: int16(v)})
+	}
+	return assignments, nil
+}
+
+func parseAddresses(value string) ([]int, error) {
+	var addrs []int
+	if value == "" {
+		return addrs, nil
+	}
+	for _, part := range strings.Split(value, ",") {
+		fromStr, toStr, isRange := strings.Cut(part, "-")
+		from, err := parseAddress(fromStr)
+		if err != nil {
+			return nil, err
+		}
+		to := from
+		if isRange {
+			if to, err = parseAddress(toStr); err != nil {
+				return nil, err
+			}
+		}
+		for addr := from; addr <= to; addr++ {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs, nil
+}
+
+func parseAddress(value string) (int, error) {
+	addr, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || addr < 0 || addr >= hackcpu.RAMSize {
+		return 0, fmt.Errorf("invalid RAM address %q", value)
+	}
+	return addr, nil
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"assembler/pkg/tstscript"
+	"fmt"
+	"log"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal("usage: tstrun file.tst...")
+	}
+
+	hasFailed := false
+	for _, scriptPath := range os.Args[1:] {
+		if err := tstscript.Run(scriptPath); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", scriptPath, err)
+			hasFailed = true
+			continue
+		}
+		fmt.Printf("%s: end of script - comparison ended successfully\n", scriptPath)
+	}
+
+	if hasFailed {
+		os.Exit(1)
+	}
+}
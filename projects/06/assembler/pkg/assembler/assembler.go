@@ -0,0 +1,292 @@
+package assembler
+
+import (
+	"assembler/pkg/code"
+	"assembler/pkg/parser"
+	"assembler/pkg/symtable"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const MaxInstructions = parser.MaxConstant + 1
+
+type ErrorList []parser.Error
+
+func (l ErrorList) Error() string {
+	var messages []string
+	for _, err := range l {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+type SymbolKind int
+
+const (
+	Label SymbolKind = iota
+	Constant
+	Variable
+	Predefined
+)
+
+func (k SymbolKind) String() string {
+	switch k {
+	case Label:
+		return "label"
+	case Constant:
+		return "constant"
+	case Variable:
+		return "variable"
+	default:
+		return "predefined"
+	}
+}
+
+type Symbol struct {
+	Name    string
+	Address int
+	Kind    SymbolKind
+	Pos     parser.Position
+}
+
+type Instruction struct {
+	Address int
+	Word    uint16
+	Pos     parser.Position
+	Source  string
+}
+
+type Program struct {
+	Instructions []Instruction
+	Symbols      []Symbol
+}
+
+type Options struct {
+	Filename string
+}
+
+func (i Instruction) Binary() string {
+	return fmt.Sprintf("%016b", i.Word)
+}
+
+func (p *Program) Words() []uint16 {
+	var words []uint16
+	for _, instruction := range p.Instructions {
+		words = append(words, instruction.Word)
+	}
+	return words
+}
+
+func (p *Program) WriteHack(w io.StringWriter) {
+	for _, instruction := range p.Instructions {
+		w.WriteString(instruction.Binary() + "\n")
+	}
+}
+
+func (p *Program) WriteListing(w io.StringWriter) {
+	labels := map[int][]Symbol{}
+	for _, symbol := range p.Symbols {
+		if symbol.Kind == Label {
+			labels[symbol.Address] = append(labels[symbol.Address], symbol)
+		}
+	}
+
+	w.WriteString("  ROM  Binary            Hex   Location              Source\n")
+	writeLabels := func(address int) {
+		for _, label := range labels[address] {
+			w.WriteString(fmt.Sprintf("%5d  %16s  %4s  %-20s  (%s)\n", address, "", "", location(label.Pos), label.Name))
+		}
+	}
+	for _, instruction := range p.Instructions {
+		writeLabels(instruction.Address)
+		w.WriteString(fmt.Sprintf("%5d  %s  %04X  %-20s  %s\n", instruction.Address, instruction.Binary(), instruction.Word, location(instruction.Pos), instruction.Source))
+	}
+	writeLabels(len(p.Instructions))
+}
+
+func location(pos parser.Position) string {
+	return filepath.Base(pos.File) + ":" + strconv.Itoa(pos.Line)
+}
+
+func (p *Program) WriteSymbols(w io.StringWriter) {
+	for _, symbol := range p.Symbols {
+		w.WriteString(fmt.Sprintf("%-10s %-24s %d\n", symbol.Kind, symbol.Name, symbol.Address))
+	}
+}
+
+func AssembleFile(filePath string) (*Program, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Assemble(f, Options{Filename: filePath})
+}
+
+func Assemble(r io.Reader, opts Options) (*Program, error) {
+	source, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	program := &Program{Instructions: nil, Symbols: nil}
+	p := parser.New(bytes.NewReader(source), opts.Filename)
+	errs := ErrorList(p.Errors())
+	addError := func(p *parser.Parser, err error) {
+		errs = append(errs, parser.Error{Pos: p.Position(), Msg: err.Error()})
+	}
+	st := symtable.New()
+	predefined := symtable.New()
+	definitions := map[string]parser.Position{}
+	pinned := map[int]string{}
+	define := func(p *parser.Parser, symbol string, address int, kind SymbolKind) {
+		if predefined.Contains(symbol) {
+			addError(p, fmt.Errorf("%s %q redefines a predefined symbol", kind, symbol))
+		} else if pos, found := definitions[symbol]; found {
+			addError(p, fmt.Errorf("symbol %q already defined at %s", symbol, pos))
+		} else {
+			st.AddEntry(symbol, address)
+			definitions[symbol] = p.Position()
+			program.Symbols = append(program.Symbols, Symbol{Name: symbol, Address: address, Kind: kind, Pos: p.Position()})
+		}
+	}
+
+	currInstructionIndex := 0
+	countInstruction := func(p *parser.Parser) {
+		if currInstructionIndex == MaxInstructions {
+			addError(p, fmt.Errorf("program exceeds %d instructions", MaxInstructions))
+		}
+		currInstructionIndex++
+	}
+	for p.HasMoreLines() {
+		p.Advance()
+		if p.GetCurrentLine() == "" {
+			continue
+		}
+
+		if err := p.CheckInstruction(); err != nil {
+			addError(p, err)
+			if p.InstructionType() == parser.LInstruction || p.InstructionType() == parser.Directive {
+				continue
+			}
+		}
+
+		switch p.InstructionType() {
+		case parser.LInstruction:
+			if currInstructionIndex > parser.MaxConstant {
+				addError(p, fmt.Errorf("label %q at ROM address %d exceeds %d", p.Symbol(), currInstructionIndex, parser.MaxConstant))
+				continue
+			}
+			define(p, p.Symbol(), currInstructionIndex, Label)
+		case parser.Directive:
+			args := p.DirectiveArgs()
+			value, _ := strconv.Atoi(args[1])
+			if p.DirectiveName() == ".equ" {
+				define(p, args[0], value, Constant)
+				continue
+			}
+			if other, found := pinned[value]; found {
+				addError(p, fmt.Errorf("RAM address %d already assigned to %q", value, other))
+				continue
+			}
+			pinned[value] = args[0]
+			define(p, args[0], value, Variable)
+		case parser.CInstruction:
+			for _, err := range checkCInstruction(p) {
+				addError(p, err)
+			}
+			countInstruction(p)
+		default:
+			countInstruction(p)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	nextVariableIndex := 16
+	p = parser.New(bytes.NewReader(source), opts.Filename)
+	for p.HasMoreLines() {
+		p.Advance()
+		if p.GetCurrentLine() == "" {
+			continue
+		}
+
+		var word uint16
+		switch p.InstructionType() {
+		case parser.AInstruction:
+			symbol := p.Symbol()
+			num, err := strconv.Atoi(symbol)
+			if err != nil {
+				if !st.Contains(symbol) {
+					for pinned[nextVariableIndex] != "" {
+						nextVariableIndex++
+					}
+					if nextVariableIndex > parser.MaxConstant {
+						addError(p, fmt.Errorf("no RAM address left for variable %q", symbol))
+						continue
+					}
+					st.AddEntry(symbol, nextVariableIndex)
+					program.Symbols = append(program.Symbols, Symbol{Name: symbol, Address: nextVariableIndex, Kind: Variable, Pos: p.Position()})
+					nextVariableIndex++
+				}
+				num = st.GetAddress(symbol)
+			}
+			word = uint16(num)
+		case parser.CInstruction:
+			comp, _ := code.Comp(p.Comp())
+			dest, _ := code.Dest(p.Dest())
+			jump, _ := code.Jump(p.Jump())
+			bits, _ := strconv.ParseUint("111"+comp+dest+jump, 2, 16)
+			word = uint16(bits)
+		case parser.LInstruction, parser.Directive:
+			continue
+		}
+
+		program.Instructions = append(program.Instructions, Instruction{
+			Address: len(program.Instructions),
+			Word:    word,
+			Pos:     p.Position(),
+			Source:  strings.TrimSpace(p.RawLine()),
+		})
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	for name, address := range predefined {
+		program.Symbols = append(program.Symbols, Symbol{Name: name, Address: address, Kind: Predefined, Pos: parser.Position{}})
+	}
+	sort.SliceStable(program.Symbols, func(i, j int) bool {
+		a, b := program.Symbols[i], program.Symbols[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Address != b.Address {
+			return a.Address < b.Address
+		}
+		return a.Name < b.Name
+	})
+	return program, nil
+}
+
+func checkCInstruction(p *parser.Parser) []error {
+	var errs []error
+	if _, err := code.Comp(p.Comp()); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := code.Dest(p.Dest()); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := code.Jump(p.Jump()); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
@@ -0,0 +1,95 @@
+package assembler
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func assembleError(t *testing.T, source string) string {
+	t.Helper()
+	program, err := Assemble(strings.NewReader(source), Options{Filename: "Test.asm"})
+	if err == nil {
+		t.Fatalf("assembled %d instructions without error", len(program.Instructions))
+	}
+	if program != nil {
+		t.Errorf("got a program together with error %v", err)
+	}
+	return err.Error()
+}
+
+func TestAssembleTooManyInstructions(t *testing.T) {
+	source := strings.Repeat("D=0\n", MaxInstructions) + "@END\n"
+	if msg := assembleError(t, source); msg != "Test.asm:32769: program exceeds 32768 instructions" {
+		t.Errorf("error = %q", msg)
+	}
+
+	source = strings.Repeat("D=0\n", MaxInstructions)
+	program, err := Assemble(strings.NewReader(source), Options{Filename: "Test.asm"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(program.Instructions) != MaxInstructions {
+		t.Errorf("assembled %d instructions, want %d", len(program.Instructions), MaxInstructions)
+	}
+}
+
+func TestAssembleLabelOutOfRange(t *testing.T) {
+	source := "@END\n0;JMP\n" + strings.Repeat("D=0\n", MaxInstructions-2) + "(END)\n"
+	if msg := assembleError(t, source); msg != `Test.asm:32769: label "END" at ROM address 32768 exceeds 32767` {
+		t.Errorf("error = %q", msg)
+	}
+
+	source = "@END\n0;JMP\n" + strings.Repeat("D=0\n", MaxInstructions-3) + "(END)\nD=0\n"
+	program, err := Assemble(strings.NewReader(source), Options{Filename: "Test.asm"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if word := program.Instructions[0].Word; word != 32767 {
+		t.Errorf("@END = %d, want 32767", word)
+	}
+}
+
+func TestAssembleValueOutOfRange(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"@32768\n", "Test.asm:1: constant 32768 exceeds 32767"},
+		{".equ BIG 32768\n@BIG\n", `Test.asm:1: invalid value "32768" in .equ, expected 0..32767`},
+		{".var BIG 40000\n@BIG\n", `Test.asm:1: invalid value "40000" in .var, expected 0..32767`},
+	}
+	for _, tt := range tests {
+		if msg := assembleError(t, tt.source); msg != tt.want {
+			t.Errorf("%q: error = %q, want %q", tt.source, msg, tt.want)
+		}
+	}
+}
+
+func TestAssembleVariablesOutOfRange(t *testing.T) {
+	var source strings.Builder
+	for i := 16; i <= MaxInstructions; i++ {
+		source.WriteString("@v" + strconv.Itoa(i) + "\n")
+	}
+	if msg := assembleError(t, source.String()); msg != `Test.asm:32753: no RAM address left for variable "v32768"` {
+		t.Errorf("error = %q", msg)
+	}
+}
+
+func TestAssembleReturnsErrorList(t *testing.T) {
+	source := "@END\n0;JMP\n" + strings.Repeat("D=0\n", MaxInstructions) + "(END)\n"
+	_, err := Assemble(strings.NewReader(source), Options{Filename: "Test.asm"})
+	var errs ErrorList
+	if !errors.As(err, &errs) {
+		t.Fatalf("error %v is not an ErrorList", err)
+	}
+	var lines []int
+	for _, e := range errs {
+		lines = append(lines, e.Pos.Line)
+	}
+	if !reflect.DeepEqual(lines, []int{32769, 32771}) {
+		t.Errorf("error lines = %v, want [32769 32771]", lines)
+	}
+}
@@ -1,8 +1,20 @@
 package code
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
+
+func Dest(value string) (string, error) {
+	if strings.Trim(value, "ADM") != "" {
+		return "", fmt.Errorf("unknown dest %q", value)
+	}
+	for _, register := range "ADM" {
+		if strings.Count(value, string(register)) > 1 {
+			return "", fmt.Errorf("repeated register %q in dest %q", register, value)
+		}
+	}
 
-func Dest(value string) string {
 	var sb strings.Builder
 
 	if strings.Contains(value, "A") {
@@ -23,89 +35,103 @@ func Dest(value string) string {
 		sb.WriteString("0")
 	}
 
-	return sb.String()
+	return sb.String(), nil
 }
 
-func Comp(value string) string {
-	switch value {
-	case "0":
-		return "0101010"
-	case "1":
-		return "0111111"
-	case "-1":
-		return "0111010"
-	case "D":
-		return "0001100"
-	case "A":
-		return "0110000"
-	case "M":
-		return "1110000"
-	case "!D":
-		return "0001111"
-	case "!A":
-		return "0110001"
-	case "!M":
-		return "1110001"
-	case "-D":
-		return "0001111"
-	case "-A":
-		return "0110011"
-	case "-M":
-		return "1110011"
-	case "D+1":
-		return "0011111"
-	case "A+1":
-		return "0110111"
-	case "M+1":
-		return "1110111"
-	case "D-1":
-		return "0001110"
-	case "A-1":
-		return "0110010"
-	case "M-1":
-		return "1110010"
-	case "D+A":
-		return "0000010"
-	case "D+M":
-		return "1000010"
-	case "D-A":
-		return "0010011"
-	case "D-M":
-		return "1010011"
-	case "A-D":
-		return "0000111"
-	case "M-D":
-		return "1000111"
-	case "D&A":
-		return "0000000"
-	case "D&M":
-		return "1000000"
-	case "D|A":
-		return "0010101"
-	case "D|M":
-		return "1010101"
-	default:
-		return "0101010"
+func Comp(value string) (string, error) {
+	if bits, found := compTable[value]; found {
+		return bits, nil
+	}
+	if len(value) == 3 && strings.ContainsRune("+&|", rune(value[1])) {
+		if bits, found := compTable[value[2:]+value[1:2]+value[:1]]; found {
+			return bits, nil
+		}
+	}
+	return "", fmt.Errorf("unknown comp %q", value)
+}
+
+var compTable = map[string]string{
+	"0":   "0101010",
+	"1":   "0111111",
+	"-1":  "0111010",
+	"D":   "0001100",
+	"A":   "0110000",
+	"M":   "1110000",
+	"!D":  "0001101",
+	"!A":  "0110001",
+	"!M":  "1110001",
+	"-D":  "0001111",
+	"-A":  "0110011",
+	"-M":  "1110011",
+	"D+1": "0011111",
+	"A+1": "0110111",
+	"M+1": "1110111",
+	"D-1": "0001110",
+	"A-1": "0110010",
+	"M-1": "1110010",
+	"D+A": "0000010",
+	"D+M": "1000010",
+	"D-A": "0010011",
+	"D-M": "1010011",
+	"A-D": "0000111",
+	"M-D": "1000111",
+	"D&A": "0000000",
+	"D&M": "1000000",
+	"D|A": "0010101",
+	"D|M": "1010101",
+}
+
+func CompMnemonic(bits string) (string, bool) {
+	for mnemonic, compBits := range compTable {
+		if compBits == bits {
+			return mnemonic, true
+		}
 	}
+	return "", false
 }
 
-func Jump(value string) string {
+func Jump(value string) (string, error) {
 	switch value {
 	case "JGT":
-		return "001"
+		return "001", nil
 	case "JEQ":
-		return "010"
+		return "010", nil
 	case "JGE":
-		return "011"
+		return "011", nil
 	case "JLT":
-		return "100"
+		return "100", nil
 	case "JNE":
-		return "101"
+		return "101", nil
 	case "JLE":
-		return "110"
+		return "110", nil
 	case "JMP":
-		return "111"
+		return "111", nil
+	case "":
+		return "000", nil
 	default:
-		return "000"
+		return "", fmt.Errorf("unknown jump %q", value)
+	}
+}
+
+func DestMnemonic(bits string) string {
+	var sb strings.Builder
+	if bits[0] == '1' {
+		sb.WriteString("A")
+	}
+	if bits[2] == '1' {
+		sb.WriteString("M")
+	}
+	if bits[1] == '1' {
+		sb.WriteString("D")
+	}
+	return sb.String()
+}
+
+func JumpMnemonic(bits string) string {
+	for _, mnemonic := range []string{"JGT", "JEQ", "JGE", "JLT", "JNE", "JLE", "JMP"} {
+		if jump, _ := Jump(mnemonic); jump == bits {
+			return mnemonic
+		}
 	}
+	return ""
 }
@@ -0,0 +1,27 @@
+package code
+
+import "testing"
+
+func TestComp(t *testing.T) {
+	tests := []struct {
+		comp string
+		want string
+	}{
+		{"!D", "0001101"},
+		{"-D", "0001111"},
+		{"!A", "0110001"},
+		{"!M", "1110001"},
+		{"D+1", "0011111"},
+		{"M+D", "1000010"},
+	}
+	for _, tt := range tests {
+		got, err := Comp(tt.comp)
+		if err != nil {
+			t.Errorf("Comp(%q): %v", tt.comp, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Comp(%q) = %s, want %s", tt.comp, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package disasm
+
+import (
+	"assembler/pkg/code"
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type Options struct {
+	Labels  bool
+	Symbols bool
+}
+
+type Program struct {
+	Lines     []string
+	Undefined []int
+}
+
+type instruction struct {
+	raw   string
+	isA   bool
+	value int
+	comp  string
+	dest  string
+	jump  string
+}
+
+func (i instruction) usesMemory() bool {
+	return !i.isA && (strings.HasPrefix(i.comp, "1") || i.dest[2] == '1')
+}
+
+// copiesA reports whether i stores the A register itself, as in the
+// @SCREEN, D=A idiom for loading a base address.
+func (i instruction) copiesA() bool {
+	return !i.isA && i.comp == "0110000" && i.dest != "000"
+}
+
+func (i instruction) jumps() bool {
+	return !i.isA && i.jump != "000"
+}
+
+func Disassemble(r io.Reader, opts Options) (*Program, error) {
+	instructions, err := readInstructions(r)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[int]string{}
+	if opts.Labels {
+		for i, inst := range instructions {
+			if inst.jumps() && i > 0 && instructions[i-1].isA && instructions[i-1].value <= len(instructions) {
+				labels[instructions[i-1].value] = "L" + strconv.Itoa(instructions[i-1].value)
+			}
+		}
+	}
+
+	program := &Program{Lines: nil, Undefined: nil}
+	for i, inst := range instructions {
+		if label, found := labels[i]; found {
+			program.Lines = append(program.Lines, "("+label+")")
+		}
+
+		if inst.isA {
+			program.Lines = append(program.Lines, "@"+aOperand(instructions, i, labels, opts))
+			continue
+		}
+
+		comp, found := code.CompMnemonic(inst.comp)
+		if !found || !strings.HasPrefix(inst.raw, "111") {
+			program.Undefined = append(program.Undefined, i)
+			program.Lines = append(program.Lines, fmt.Sprintf("// ROM[%d]: undefined instruction %s", i, inst.raw))
+			continue
+		}
+		line := comp
+		if dest := code.DestMnemonic(inst.dest); dest != "" {
+			line = dest + "=" + line
+		}
+		if jump := code.JumpMnemonic(inst.jump); jump != "" {
+			line += ";" + jump
+		}
+		program.Lines = append(program.Lines, line)
+	}
+	if label, found := labels[len(instructions)]; found {
+		program.Lines = append(program.Lines, "("+label+")")
+	}
+	return program, nil
+}
+
+func aOperand(instructions []instruction, i int, labels map[int]string, opts Options) string {
+	value := instructions[i].value
+	if i+1 < len(instructions) {
+		next := instructions[i+1]
+		if label, found := labels[value]; found && next.jumps() {
+			return label
+		}
+		if opts.Symbols && (next.usesMemory() || next.copiesA()) {
+			switch {
+			case value == 16384:
+				return "SCREEN"
+			case value == 24576:
+				return "KBD"
+			case value < 16 && next.usesMemory():
+				return "R" + strconv.Itoa(value)
+			}
+		}
+	}
+	return strconv.Itoa(value)
+}
+
+func readInstructions(r io.Reader) ([]instruction, error) {
+	var instructions []instruction
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if len(line) != 16 || strings.Trim(line, "01") != "" {
+			return nil, fmt.Errorf("line %d: expected 16 bits, found %q", lineNumber, line)
+		}
+
+		if line[0] == '0' {
+			value, _ := strconv.ParseInt(line, 2, 16)
+			instructions = append(instructions, instruction{raw: line, isA: true, value: int(value), comp: "", dest: "", jump: ""})
+			continue
+		}
+		instructions = append(instructions, instruction{raw: line, isA: false, value: 0, comp: line[3:10], dest: line[10:13], jump: line[13:16]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return instructions, nil
+}
@@ -0,0 +1,194 @@
+package hackcpu
+
+import (
+	"assembler/pkg/romfile"
+	"fmt"
+	"io"
+)
+
+const (
+	ROMSize    = 32768
+	RAMSize    = 24577
+	ScreenAddr = 16384
+	ScreenSize = 8192
+	KBDAddr    = 24576
+)
+
+type CPU struct {
+	rom         [ROMSize]uint16
+	ram         [RAMSize]int16
+	a           int16
+	d           int16
+	pc          int
+	programSize int
+	cycles      int
+}
+
+func New() *CPU {
+	return &CPU{
+		a:           0,
+		d:           0,
+		pc:          0,
+		programSize: 0,
+		cycles:      0,
+	}
+}
+
+func (c *CPU) Load(r io.Reader, format romfile.Format) error {
+	program, err := romfile.Read(r, format)
+	if err != nil {
+		return err
+	}
+
+	c.LoadProgram(program)
+	return nil
+}
+
+func (c *CPU) LoadProgram(program []uint16) {
+	c.rom = [ROMSize]uint16{}
+	copy(c.rom[:], program)
+	c.programSize = len(program)
+	c.Reset()
+}
+
+func (c *CPU) Reset() {
+	c.pc = 0
+	c.cycles = 0
+}
+
+func (c *CPU) Step() error {
+	if c.pc < 0 || c.pc >= ROMSize {
+		return fmt.Errorf("program counter %d out of range", c.pc)
+	}
+	instruction := c.rom[c.pc]
+	c.cycles++
+
+	if instruction&0x8000 == 0 {
+		c.a = int16(instruction)
+		c.pc++
+		return nil
+	}
+
+	addr := int(uint16(c.a))
+	usesM := instruction&0x1000 != 0
+	writesM := instruction&0x0008 != 0
+	if (usesM || writesM) && addr >= RAMSize {
+		return fmt.Errorf("ROM[%d]: memory address %d out of range", c.pc, addr)
+	}
+
+	y := c.a
+	if usesM {
+		y = c.ram[addr]
+	}
+	out := alu(c.d, y, instruction>>6)
+
+	if writesM {
+		c.ram[addr] = out
+	}
+	if instruction&0x0020 != 0 {
+		c.a = out
+	}
+	if instruction&0x0010 != 0 {
+		c.d = out
+	}
+
+	if shouldJump(out, instruction&0x0007) {
+		c.pc = addr
+	} else {
+		c.pc++
+	}
+	return nil
+}
+
+func (c *CPU) Run(cycles int) error {
+	for i := 0; i < cycles; i++ {
+		if err := c.Step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func alu(x, y int16, control uint16) int16 {
+	if control&0x20 != 0 {
+		x = 0
+	}
+	if control&0x10 != 0 {
+		x = ^x
+	}
+	if control&0x08 != 0 {
+		y = 0
+	}
+	if control&0x04 != 0 {
+		y = ^y
+	}
+
+	var out int16
+	if control&0x02 != 0 {
+		out = x + y
+	} else {
+		out = x & y
+	}
+	if control&0x01 != 0 {
+		out = ^out
+	}
+	return out
+}
+
+func shouldJump(out int16, jump uint16) bool {
+	return jump&0x4 != 0 && out < 0 ||
+		jump&0x2 != 0 && out == 0 ||
+		jump&0x1 != 0 && out > 0
+}
+
+func (c *CPU) A() int16 {
+	return c.a
+}
+
+func (c *CPU) D() int16 {
+	return c.d
+}
+
+func (c *CPU) SetA(value int16) {
+	c.a = value
+}
+
+func (c *CPU) SetD(value int16) {
+	c.d = value
+}
+
+func (c *CPU) PC() int {
+	return c.pc
+}
+
+func (c *CPU) SetPC(pc int) {
+	c.pc = pc
+}
+
+func (c *CPU) Cycles() int {
+	return c.cycles
+}
+
+func (c *CPU) ProgramSize() int {
+	return c.programSize
+}
+
+func (c *CPU) ROM(addr int) uint16 {
+	return c.rom[addr]
+}
+
+func (c *CPU) RAM(addr int) int16 {
+	return c.ram[addr]
+}
+
+func (c *CPU) SetRAM(addr int, value int16) {
+	c.ram[addr] = value
+}
+
+func (c *CPU) Screen() []int16 {
+	return c.ram[ScreenAddr : ScreenAddr+ScreenSize]
+}
+
+func (c *CPU) SetKey(key int16) {
+	c.ram[KBDAddr] = key
+}
@@ -1,15 +1,18 @@
 package parser
 
 import (
-	"bufio"
-	"log"
-	"os"
+	"fmt"
+	"io"
+	"strconv"
 	"strings"
 )
 
+const MaxConstant = 32767
+
 type Parser struct {
 	filePath             string
-	lines                []string
+	lines                []sourceLine
+	errors               []Error
 	currLineIndex        int
 	currInstructionIndex int
 }
@@ -20,34 +23,39 @@ const (
 	AInstruction InstructionType = iota
 	CInstruction
 	LInstruction
+	Directive
 )
 
-func New(filePath string) *Parser {
+func New(r io.Reader, filePath string) *Parser {
+	pp := newPreprocessor()
+	lines := pp.readSource(r, filePath)
 	return &Parser{
 		filePath:      filePath,
-		lines:         readLines(filePath),
+		lines:         lines,
+		errors:        pp.errors,
 		currLineIndex: -1,
 	}
 }
 
-func readLines(filePath string) []string {
-	file, err := os.Open(filePath)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer file.Close()
-
-	var lines []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
-	}
-	return lines
+func (p *Parser) Errors() []Error {
+	return p.errors
 }
 
 func (p *Parser) GetCurrentLine() string {
-	line, _, _ := strings.Cut(p.lines[p.currLineIndex], "//")
-	return strings.TrimSpace(line)
+	line, _, _ := strings.Cut(p.lines[p.currLineIndex].text, "//")
+	return strings.Join(strings.Fields(line), "")
+}
+
+func (p *Parser) RawLine() string {
+	return p.lines[p.currLineIndex].text
+}
+
+func (p *Parser) Position() Position {
+	return p.lines[p.currLineIndex].pos
+}
+
+func (p *Parser) LineNumber() int {
+	return p.lines[p.currLineIndex].pos.Line
 }
 
 func (p *Parser) HasMoreLines() bool {
@@ -72,10 +80,14 @@ func (p *Parser) InstructionType() InstructionType {
 		return AInstruction
 	}
 
-	if strings.HasPrefix(line, "(") && strings.HasSuffix(line, ")") {
+	if strings.HasPrefix(line, "(") {
 		return LInstruction
 	}
 
+	if strings.HasPrefix(line, ".") {
+		return Directive
+	}
+
 	return CInstruction
 }
 
@@ -93,6 +105,20 @@ func (p *Parser) Symbol() string {
 	return ""
 }
 
+func (p *Parser) DirectiveName() string {
+	if p.InstructionType() != Directive {
+		return ""
+	}
+	return directiveFields(p.RawLine())[0]
+}
+
+func (p *Parser) DirectiveArgs() []string {
+	if p.InstructionType() != Directive {
+		return nil
+	}
+	return splitArgs(directiveFields(p.RawLine())[1:])
+}
+
 func (p *Parser) Dest() string {
 	if p.InstructionType() != CInstruction {
 		return ""
@@ -130,3 +156,75 @@ func (p *Parser) Jump() string {
 	_, jump, _ := strings.Cut(line, ";")
 	return jump
 }
+
+func (p *Parser) CheckInstruction() error {
+	line := p.GetCurrentLine()
+
+	switch p.InstructionType() {
+	case AInstruction:
+		symbol := line[1:]
+		if symbol != "" && isDigit(symbol[0]) {
+			num, err := strconv.Atoi(symbol)
+			if err != nil {
+				return fmt.Errorf("invalid constant %q", symbol)
+			}
+			if num > MaxConstant {
+				return fmt.Errorf("constant %d exceeds %d", num, MaxConstant)
+			}
+			return nil
+		}
+		if !IsValidSymbol(symbol) {
+			return fmt.Errorf("invalid symbol %q", symbol)
+		}
+	case Directive:
+		return p.checkDirective()
+	case LInstruction:
+		if len(line) < 2 || !strings.HasSuffix(line, ")") {
+			return fmt.Errorf("malformed label %q", line)
+		}
+		symbol := line[1 : len(line)-1]
+		if symbol != "" && isDigit(symbol[0]) {
+			return fmt.Errorf("label %q must not start with a digit", symbol)
+		}
+		if !IsValidSymbol(symbol) {
+			return fmt.Errorf("malformed label %q", line)
+		}
+	}
+	return nil
+}
+
+func (p *Parser) checkDirective() error {
+	name := p.DirectiveName()
+	args := p.DirectiveArgs()
+	switch name {
+	case ".equ", ".var":
+		if len(args) != 2 {
+			return fmt.Errorf("expected %s NAME value", name)
+		}
+		if !IsValidSymbol(args[0]) {
+			return fmt.Errorf("invalid symbol %q in %s", args[0], name)
+		}
+		if num, err := strconv.Atoi(args[1]); err != nil || num < 0 || num > MaxConstant {
+			return fmt.Errorf("invalid value %q in %s, expected 0..%d", args[1], name, MaxConstant)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown directive %s", name)
+}
+
+func IsValidSymbol(symbol string) bool {
+	if symbol == "" || isDigit(symbol[0]) {
+		return false
+	}
+	for i := 0; i < len(symbol); i++ {
+		c := symbol[i]
+		if !isDigit(c) && !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !strings.ContainsRune("_.$:", rune(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
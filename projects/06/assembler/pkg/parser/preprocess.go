@@ -0,0 +1,285 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const maxExpansionDepth = 32
+
+type Position struct {
+	File     string
+	Line     int
+	Macro    string
+	CallSite *Position
+}
+
+func (p Position) String() string {
+	s := p.File + ":" + strconv.Itoa(p.Line)
+	if p.CallSite != nil {
+		s += fmt.Sprintf(" (expanded from %s at %s)", p.Macro, p.CallSite)
+	}
+	return s
+}
+
+type Error struct {
+	Pos Position
+	Msg string
+}
+
+func (e Error) Error() string {
+	return e.Pos.String() + ": " + e.Msg
+}
+
+type sourceLine struct {
+	text string
+	pos  Position
+}
+
+type macro struct {
+	name   string
+	params []string
+	labels []string
+	body   []sourceLine
+	pos    Position
+}
+
+type preprocessor struct {
+	macros       map[string]*macro
+	includeStack []string
+	expansions   int
+	errors       []Error
+}
+
+func newPreprocessor() *preprocessor {
+	return &preprocessor{
+		macros:       map[string]*macro{},
+		includeStack: nil,
+		expansions:   0,
+		errors:       nil,
+	}
+}
+
+func (pp *preprocessor) errorf(pos Position, format string, args ...any) {
+	pp.errors = append(pp.errors, Error{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+func directiveFields(text string) []string {
+	code, _, _ := strings.Cut(text, "//")
+	return strings.Fields(code)
+}
+
+func (pp *preprocessor) readFile(filePath string, includePos *Position) []sourceLine {
+	for _, included := range pp.includeStack {
+		if included == filePath {
+			pp.errorf(*includePos, "recursive include of %q", filePath)
+			return nil
+		}
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		pp.errorf(*includePos, "%v", err)
+		return nil
+	}
+	defer file.Close()
+	return pp.readSource(file, filePath)
+}
+
+func (pp *preprocessor) readSource(r io.Reader, filePath string) []sourceLine {
+	var lines []sourceLine
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		lines = append(lines, sourceLine{text: scanner.Text(), pos: Position{File: filePath, Line: lineNumber, Macro: "", CallSite: nil}})
+	}
+	if err := scanner.Err(); err != nil {
+		pp.errorf(Position{File: filePath, Line: lineNumber, Macro: "", CallSite: nil}, "%v", err)
+	}
+
+	pp.includeStack = append(pp.includeStack, filePath)
+	defer func() { pp.includeStack = pp.includeStack[:len(pp.includeStack)-1] }()
+	return pp.process(lines, 0)
+}
+
+func (pp *preprocessor) process(lines []sourceLine, depth int) []sourceLine {
+	var output []sourceLine
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		fields := directiveFields(line.text)
+		if len(fields) == 0 {
+			output = append(output, line)
+			continue
+		}
+
+		switch {
+		case fields[0] == ".include":
+			output = append(output, pp.include(line, fields)...)
+		case fields[0] == ".macro":
+			i = pp.defineMacro(lines, i, fields)
+		case fields[0] == ".endm":
+			pp.errorf(line.pos, ".endm without .macro")
+		case pp.macros[fields[0]] != nil:
+			output = append(output, pp.expand(pp.macros[fields[0]], line, fields[1:], depth)...)
+		case fields[0] == ".table":
+			output = append(output, pp.table(line, fields)...)
+		default:
+			output = append(output, line)
+		}
+	}
+	return output
+}
+
+func (pp *preprocessor) include(line sourceLine, fields []string) []sourceLine {
+	if len(fields) != 2 || len(fields[1]) < 2 || !strings.HasPrefix(fields[1], "\"") || !strings.HasSuffix(fields[1], "\"") {
+		pp.errorf(line.pos, "expected .include \"file.asm\"")
+		return nil
+	}
+	includePath := filepath.Join(filepath.Dir(line.pos.File), fields[1][1:len(fields[1])-1])
+	pos := line.pos
+	return pp.readFile(includePath, &pos)
+}
+
+func (pp *preprocessor) table(line sourceLine, fields []string) []sourceLine {
+	values := splitArgs(fields[1:])
+	if len(values) == 0 {
+		pp.errorf(line.pos, "expected .table value, ...")
+		return nil
+	}
+	var output []sourceLine
+	for _, value := range values {
+		output = append(output, sourceLine{text: "@" + value, pos: line.pos})
+	}
+	return output
+}
+
+func splitArgs(fields []string) []string {
+	var args []string
+	for _, arg := range strings.Split(strings.Join(fields, ","), ",") {
+		if arg != "" {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
+
+func (pp *preprocessor) defineMacro(lines []sourceLine, start int, fields []string) int {
+	pos := lines[start].pos
+	var params []string
+	if len(fields) > 1 {
+		params = splitArgs(fields[2:])
+	}
+
+	end := start + 1
+	for ; end < len(lines); end++ {
+		directive := directiveFields(lines[end].text)
+		if len(directive) > 0 && directive[0] == ".endm" {
+			break
+		}
+		if len(directive) > 0 && directive[0] == ".macro" {
+			pp.errorf(lines[end].pos, "nested .macro definitions are not supported")
+		}
+	}
+	if end == len(lines) {
+		pp.errorf(pos, "missing .endm")
+	}
+
+	if len(fields) < 2 || !IsValidSymbol(fields[1]) {
+		pp.errorf(pos, "expected .macro NAME [param, ...]")
+		return end
+	}
+	name := fields[1]
+	if existing, found := pp.macros[name]; found {
+		pp.errorf(pos, "macro %s already defined at %s", name, existing.pos)
+		return end
+	}
+	for i, param := range params {
+		if !IsValidSymbol(param) {
+			pp.errorf(pos, "invalid macro parameter %q", param)
+		}
+		for _, other := range params[:i] {
+			if other == param {
+				pp.errorf(pos, "duplicate macro parameter %q", param)
+			}
+		}
+	}
+
+	m := &macro{name: name, params: params, labels: nil, body: lines[start+1 : end], pos: pos}
+	for _, line := range m.body {
+		code := strings.Join(directiveFields(line.text), "")
+		if strings.HasPrefix(code, "(") && strings.HasSuffix(code, ")") {
+			m.labels = append(m.labels, code[1:len(code)-1])
+		}
+	}
+	pp.macros[name] = m
+	return end
+}
+
+func (pp *preprocessor) expand(m *macro, call sourceLine, fields []string, depth int) []sourceLine {
+	if depth >= maxExpansionDepth {
+		pp.errorf(call.pos, "macro %s expanded too deeply (recursive macro?)", m.name)
+		return nil
+	}
+
+	args := splitArgs(fields)
+	if len(args) != len(m.params) {
+		pp.errorf(call.pos, "macro %s (defined at %s) expects %d arguments, found %d", m.name, m.pos, len(m.params), len(args))
+		return nil
+	}
+
+	pp.expansions++
+	replacements := map[string]string{}
+	for _, label := range m.labels {
+		replacements[label] = fmt.Sprintf("%s$%s.%d", m.name, label, pp.expansions)
+	}
+	for i, param := range m.params {
+		replacements[param] = args[i]
+	}
+
+	callSite := call.pos
+	var body []sourceLine
+	for _, line := range m.body {
+		pos := line.pos
+		pos.Macro = m.name
+		pos.CallSite = &callSite
+		body = append(body, sourceLine{text: replaceSymbols(line.text, replacements), pos: pos})
+	}
+	return pp.process(body, depth+1)
+}
+
+func replaceSymbols(text string, replacements map[string]string) string {
+	code, comment, hasComment := strings.Cut(text, "//")
+
+	var sb strings.Builder
+	for i := 0; i < len(code); {
+		if !isSymbolChar(code[i]) {
+			sb.WriteByte(code[i])
+			i++
+			continue
+		}
+		start := i
+		for i < len(code) && isSymbolChar(code[i]) {
+			i++
+		}
+		word := code[start:i]
+		if replacement, found := replacements[word]; found {
+			word = replacement
+		}
+		sb.WriteString(word)
+	}
+
+	if hasComment {
+		sb.WriteString("//" + comment)
+	}
+	return sb.String()
+}
+
+func isSymbolChar(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || strings.ContainsRune("_.$:", rune(c))
+}
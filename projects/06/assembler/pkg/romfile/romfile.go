@@ -0,0 +1,279 @@
+package romfile
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const MaxWords = 32768
+
+type Format int
+
+const (
+	Hack Format = iota
+	Binary
+	IntelHex
+	MemB
+	MemH
+)
+
+var formatNames = []string{"hack", "bin", "ihex", "memb", "memh"}
+var formatExts = []string{".hack", ".bin", ".hex", ".memb", ".memh"}
+
+func (f Format) String() string {
+	return formatNames[f]
+}
+
+func (f Format) Ext() string {
+	return formatExts[f]
+}
+
+func ParseFormat(name string) (Format, error) {
+	for i, formatName := range formatNames {
+		if name == formatName {
+			return Format(i), nil
+		}
+	}
+	return Hack, fmt.Errorf("unknown format %q, expected one of %s", name, strings.Join(formatNames, ", "))
+}
+
+func FormatOf(filePath string) (Format, error) {
+	ext := filepath.Ext(filePath)
+	for i, formatExt := range formatExts {
+		if ext == formatExt {
+			return Format(i), nil
+		}
+	}
+	return Hack, fmt.Errorf("%s: cannot tell the format from extension %q", filePath, ext)
+}
+
+func Write(w io.Writer, words []uint16, format Format) error {
+	switch format {
+	case Binary:
+		return binary.Write(w, binary.BigEndian, words)
+	case IntelHex:
+		return writeIntelHex(w, words)
+	}
+
+	bw := bufio.NewWriter(w)
+	if format == MemB || format == MemH {
+		fmt.Fprintf(bw, "// %d words\n", len(words))
+	}
+	for _, word := range words {
+		switch format {
+		case MemH:
+			fmt.Fprintf(bw, "%04x\n", word)
+		default:
+			fmt.Fprintf(bw, "%016b\n", word)
+		}
+	}
+	return bw.Flush()
+}
+
+func writeIntelHex(w io.Writer, words []uint16) error {
+	bw := bufio.NewWriter(w)
+	data := make([]byte, 2*len(words))
+	for i, word := range words {
+		binary.BigEndian.PutUint16(data[2*i:], word)
+	}
+	for addr := 0; addr < len(data); addr += 16 {
+		end := addr + 16
+		if end > len(data) {
+			end = len(data)
+		}
+		writeRecord(bw, addr, 0x00, data[addr:end])
+	}
+	writeRecord(bw, 0, 0x01, nil)
+	return bw.Flush()
+}
+
+func writeRecord(w *bufio.Writer, addr int, recordType byte, data []byte) {
+	record := []byte{byte(len(data)), byte(addr >> 8), byte(addr), recordType}
+	record = append(record, data...)
+	record = append(record, checksum(record))
+	fmt.Fprintf(w, ":%s\n", strings.ToUpper(hex.EncodeToString(record)))
+}
+
+func checksum(record []byte) byte {
+	var sum byte
+	for _, b := range record {
+		sum += b
+	}
+	return -sum
+}
+
+func ReadFile(filePath string) ([]uint16, error) {
+	format, err := FormatOf(filePath)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	words, err := Read(f, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	return words, nil
+}
+
+func Read(r io.Reader, format Format) ([]uint16, error) {
+	switch format {
+	case Binary:
+		return readBinary(r)
+	case IntelHex:
+		return readIntelHex(r)
+	case MemB:
+		return readMem(r, 2)
+	case MemH:
+		return readMem(r, 16)
+	default:
+		return readHack(r)
+	}
+}
+
+func readHack(r io.Reader) ([]uint16, error) {
+	var words []uint16
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if len(line) != 16 {
+			return nil, fmt.Errorf("line %d: expected 16 bits, found %q", lineNumber, line)
+		}
+		word, err := strconv.ParseUint(line, 2, 16)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid instruction %q", lineNumber, line)
+		}
+		if len(words) == MaxWords {
+			return nil, fmt.Errorf("line %d: program exceeds %d words", lineNumber, MaxWords)
+		}
+		words = append(words, uint16(word))
+	}
+	return words, scanner.Err()
+}
+
+func readBinary(r io.Reader) ([]uint16, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return bytesToWords(data)
+}
+
+func bytesToWords(data []byte) ([]uint16, error) {
+	if len(data)%2 != 0 {
+		return nil, fmt.Errorf("odd file size %d, expected 16-bit words", len(data))
+	}
+	if len(data)/2 > MaxWords {
+		return nil, fmt.Errorf("program exceeds %d words", MaxWords)
+	}
+	words := make([]uint16, len(data)/2)
+	for i := range words {
+		words[i] = binary.BigEndian.Uint16(data[2*i:])
+	}
+	return words, nil
+}
+
+func readIntelHex(r io.Reader) ([]uint16, error) {
+	var data []byte
+	base := 0
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, ":") {
+			return nil, fmt.Errorf("line %d: record must start with ':'", lineNumber)
+		}
+		record, err := hex.DecodeString(line[1:])
+		if err != nil || len(record) < 5 || len(record) != 5+int(record[0]) {
+			return nil, fmt.Errorf("line %d: malformed record %q", lineNumber, line)
+		}
+		if checksum(record[:len(record)-1]) != record[len(record)-1] {
+			return nil, fmt.Errorf("line %d: checksum mismatch", lineNumber)
+		}
+
+		payload := record[4 : len(record)-1]
+		switch record[3] {
+		case 0x00:
+			addr := base + int(record[1])<<8 + int(record[2])
+			if addr+len(payload) > 2*MaxWords {
+				return nil, fmt.Errorf("line %d: address %#x exceeds %d words", lineNumber, addr, MaxWords)
+			}
+			for len(data) < addr+len(payload) {
+				data = append(data, 0)
+			}
+			copy(data[addr:], payload)
+		case 0x01:
+			return bytesToWords(data)
+		case 0x02, 0x04:
+			if len(payload) != 2 {
+				return nil, fmt.Errorf("line %d: malformed address record", lineNumber)
+			}
+			base = int(binary.BigEndian.Uint16(payload)) << 16
+			if record[3] == 0x02 {
+				base >>= 12
+			}
+		case 0x03, 0x05:
+		default:
+			return nil, fmt.Errorf("line %d: unknown record type %02X", lineNumber, record[3])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("missing end-of-file record")
+}
+
+func readMem(r io.Reader, radix int) ([]uint16, error) {
+	var words []uint16
+	addr := 0
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		code, _, _ := strings.Cut(scanner.Text(), "//")
+		for _, field := range strings.Fields(code) {
+			if strings.HasPrefix(field, "@") {
+				next, err := strconv.ParseUint(field[1:], 16, 32)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid address %q", lineNumber, field)
+				}
+				addr = int(next)
+				continue
+			}
+
+			word, err := strconv.ParseUint(strings.ReplaceAll(field, "_", ""), radix, 16)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid word %q", lineNumber, field)
+			}
+			if addr >= MaxWords {
+				return nil, fmt.Errorf("line %d: address %d exceeds %d words", lineNumber, addr, MaxWords)
+			}
+			for len(words) <= addr {
+				words = append(words, 0)
+			}
+			words[addr] = uint16(word)
+			addr++
+		}
+	}
+	return words, scanner.Err()
+}
@@ -0,0 +1,86 @@
+package tstscript
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type column struct {
+	name         string
+	format       byte
+	leftPadding  int
+	width        int
+	rightPadding int
+}
+
+func parseColumn(spec string) (column, error) {
+	name, format, found := strings.Cut(spec, "%")
+	if !found {
+		return column{name: spec, format: 'D', leftPadding: 1, width: 6, rightPadding: 1}, nil
+	}
+
+	parts := strings.Split(format[1:], ".")
+	if len(format) < 2 || len(parts) != 3 || !strings.ContainsRune("BDXS", rune(format[0])) {
+		return column{}, fmt.Errorf("invalid output format %q", spec)
+	}
+	var sizes [3]int
+	for i, part := range parts {
+		size, err := strconv.Atoi(part)
+		if err != nil || size < 0 {
+			return column{}, fmt.Errorf("invalid output format %q", spec)
+		}
+		sizes[i] = size
+	}
+	return column{
+		name:         name,
+		format:       format[0],
+		leftPadding:  sizes[0],
+		width:        sizes[1],
+		rightPadding: sizes[2],
+	}, nil
+}
+
+func (c column) header() string {
+	total := c.leftPadding + c.width + c.rightPadding
+	name := c.name
+	if len(name) > total {
+		name = name[:total]
+	}
+	left := (total - len(name)) / 2
+	return strings.Repeat(" ", left) + name + strings.Repeat(" ", total-len(name)-left)
+}
+
+func (c column) cell(value string) string {
+	return strings.Repeat(" ", c.leftPadding) + value + strings.Repeat(" ", c.rightPadding)
+}
+
+func (c column) formatValue(value int16) string {
+	var text string
+	switch c.format {
+	case 'B':
+		text = fmt.Sprintf("%016b", uint16(value))
+		return c.cell(text[len(text)-min(c.width, len(text)):])
+	case 'X':
+		text = fmt.Sprintf("%04X", uint16(value))
+		return c.cell(text[len(text)-min(c.width, len(text)):])
+	case 'S':
+		return c.formatString(strconv.Itoa(int(value)))
+	default:
+		return c.cell(fmt.Sprintf("%*d", c.width, value))
+	}
+}
+
+func (c column) formatString(value string) string {
+	if len(value) > c.width {
+		value = value[:c.width]
+	}
+	return c.cell(fmt.Sprintf("%-*s", c.width, value))
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
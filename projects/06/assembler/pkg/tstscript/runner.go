@@ -0,0 +1,352 @@
+package tstscript
+
+import (
+	"assembler/pkg/assembler"
+	"assembler/pkg/hackcpu"
+	"assembler/pkg/romfile"
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type ComparisonError struct {
+	Line     int
+	Expected string
+	Actual   string
+}
+
+func (e *ComparisonError) Error() string {
+	return fmt.Sprintf("comparison failure at line %d: expected %q, found %q", e.Line, e.Expected, e.Actual)
+}
+
+type Runner struct {
+	dir         string
+	cpu         *hackcpu.CPU
+	reset       bool
+	time        int
+	isHalfCycle bool
+	columns     []column
+	outputPath  string
+	comparePath string
+	output      []string
+}
+
+func New(dir string) *Runner {
+	return &Runner{
+		dir:         dir,
+		cpu:         hackcpu.New(),
+		reset:       false,
+		time:        0,
+		isHalfCycle: false,
+		columns:     nil,
+		outputPath:  "",
+		comparePath: "",
+		output:      nil,
+	}
+}
+
+func Run(scriptPath string) error {
+	source, err := os.ReadFile(scriptPath)
+	if err != nil {
+		return err
+	}
+	commands, err := parse(string(source))
+	if err != nil {
+		return err
+	}
+
+	r := New(filepath.Dir(scriptPath))
+	if err := r.execute(commands); err != nil {
+		return err
+	}
+	if err := r.writeOutput(); err != nil {
+		return err
+	}
+	return r.compare()
+}
+
+func (r *Runner) execute(commands []command) error {
+	for _, cmd := range commands {
+		if err := r.executeCommand(cmd); err != nil {
+			return fmt.Errorf("line %d: %w", cmd.line, err)
+		}
+	}
+	return nil
+}
+
+func (r *Runner) executeCommand(cmd command) error {
+	args := cmd.words[1:]
+	switch cmd.words[0] {
+	case "load":
+		return r.load(args)
+	case "ROM32K":
+		if len(args) != 2 || args[0] != "load" {
+			return fmt.Errorf("expected ROM32K load <file>")
+		}
+		return r.loadProgram(args[1])
+	case "output-file":
+		if len(args) != 1 {
+			return fmt.Errorf("expected output-file <file>")
+		}
+		r.outputPath = filepath.Join(r.dir, args[0])
+	case "compare-to":
+		if len(args) != 1 {
+			return fmt.Errorf("expected compare-to <file>")
+		}
+		r.comparePath = filepath.Join(r.dir, args[0])
+	case "output-list":
+		return r.setOutputList(args)
+	case "set":
+		if len(args) != 2 {
+			return fmt.Errorf("expected set <variable> <value>")
+		}
+		return r.set(args[0], args[1])
+	case "repeat":
+		if len(args) != 1 {
+			return fmt.Errorf("expected repeat <count> { ... }")
+		}
+		count, err := strconv.Atoi(args[0])
+		if err != nil || count < 0 {
+			return fmt.Errorf("invalid repeat count %q", args[0])
+		}
+		for i := 0; i < count; i++ {
+			if err := r.execute(cmd.body); err != nil {
+				return err
+			}
+		}
+	case "ticktock":
+		if err := r.tick(); err != nil {
+			return err
+		}
+		return r.tock()
+	case "tick":
+		return r.tick()
+	case "tock":
+		return r.tock()
+	case "output":
+		return r.writeLine()
+	case "echo", "clear-echo":
+	default:
+		return fmt.Errorf("unsupported command %q", cmd.words[0])
+	}
+	return nil
+}
+
+func (r *Runner) load(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected load <file>")
+	}
+	switch filepath.Ext(args[0]) {
+	case ".hdl":
+		if args[0] != "Computer.hdl" {
+			return fmt.Errorf("unsupported chip %q, only Computer.hdl can be simulated", args[0])
+		}
+		return nil
+	default:
+		return r.loadProgram(args[0])
+	}
+}
+
+func (r *Runner) loadProgram(name string) error {
+	path := filepath.Join(r.dir, name)
+	if filepath.Ext(name) == ".asm" {
+		program, err := assembler.AssembleFile(path)
+		if err != nil {
+			return err
+		}
+		r.cpu.LoadProgram(program.Words())
+		return nil
+	}
+
+	program, err := romfile.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	r.cpu.LoadProgram(program)
+	return nil
+}
+
+func (r *Runner) setOutputList(specs []string) error {
+	r.columns = nil
+	for _, spec := range specs {
+		col, err := parseColumn(spec)
+		if err != nil {
+			return err
+		}
+		if _, err := r.get(col.name); err != nil {
+			return err
+		}
+		r.columns = append(r.columns, col)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("|")
+	for _, col := range r.columns {
+		sb.WriteString(col.header() + "|")
+	}
+	r.output = append(r.output, sb.String())
+	return nil
+}
+
+func (r *Runner) writeLine() error {
+	var sb strings.Builder
+	sb.WriteString("|")
+	for _, col := range r.columns {
+		if col.name == "time" {
+			sb.WriteString(col.formatString(r.timeString()) + "|")
+			continue
+		}
+		value, err := r.get(col.name)
+		if err != nil {
+			return err
+		}
+		sb.WriteString(col.formatValue(value) + "|")
+	}
+	r.output = append(r.output, sb.String())
+	return nil
+}
+
+func (r *Runner) timeString() string {
+	if r.isHalfCycle {
+		return strconv.Itoa(r.time) + "+"
+	}
+	return strconv.Itoa(r.time)
+}
+
+func (r *Runner) tick() error {
+	r.isHalfCycle = true
+	return nil
+}
+
+func (r *Runner) tock() error {
+	r.isHalfCycle = false
+	r.time++
+	if r.reset {
+		r.cpu.SetPC(0)
+		return nil
+	}
+	return r.cpu.Step()
+}
+
+func (r *Runner) get(name string) (int16, error) {
+	switch name {
+	case "time":
+		return int16(r.time), nil
+	case "reset":
+		if r.reset {
+			return 1, nil
+		}
+		return 0, nil
+	case "A", "ARegister[]", "ARegister[0]":
+		return r.cpu.A(), nil
+	case "D", "DRegister[]", "DRegister[0]":
+		return r.cpu.D(), nil
+	case "PC", "PC[]":
+		return int16(r.cpu.PC()), nil
+	}
+
+	addr, err := r.parseAddress(name)
+	if err != nil {
+		return 0, err
+	}
+	if strings.HasPrefix(name, "ROM") {
+		return int16(r.cpu.ROM(addr)), nil
+	}
+	return r.cpu.RAM(addr), nil
+}
+
+func (r *Runner) set(name, valueText string) error {
+	value, err := parseValue(valueText)
+	if err != nil {
+		return err
+	}
+	switch name {
+	case "reset":
+		r.reset = value != 0
+		return nil
+	case "A", "ARegister[]", "ARegister[0]":
+		r.cpu.SetA(value)
+		return nil
+	case "D", "DRegister[]", "DRegister[0]":
+		r.cpu.SetD(value)
+		return nil
+	case "PC", "PC[]":
+		r.cpu.SetPC(int(uint16(value)))
+		return nil
+	}
+
+	addr, err := r.parseAddress(name)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(name, "ROM") {
+		return fmt.Errorf("cannot set %s", name)
+	}
+	r.cpu.SetRAM(addr, value)
+	return nil
+}
+
+func (r *Runner) parseAddress(name string) (int, error) {
+	memory, index, found := strings.Cut(name, "[")
+	if !found || !strings.HasSuffix(index, "]") {
+		return 0, fmt.Errorf("unknown variable %q", name)
+	}
+	size := 0
+	switch memory {
+	case "RAM":
+		size = hackcpu.RAMSize
+	case "RAM16K":
+		size = hackcpu.ScreenAddr
+	case "ROM", "ROM32K":
+		size = hackcpu.ROMSize
+	default:
+		return 0, fmt.Errorf("unknown variable %q", name)
+	}
+	addr, err := strconv.Atoi(strings.TrimSuffix(index, "]"))
+	if err != nil || addr < 0 || addr >= size {
+		return 0, fmt.Errorf("invalid address in %q", name)
+	}
+	return addr, nil
+}
+
+func (r *Runner) writeOutput() error {
+	if r.outputPath == "" {
+		return nil
+	}
+	return os.WriteFile(r.outputPath, []byte(strings.Join(r.output, "\n")+"\n"), 0644)
+}
+
+func (r *Runner) compare() error {
+	if r.comparePath == "" {
+		return nil
+	}
+	f, err := os.Open(r.comparePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	lineNumber := 0
+	for scanner.Scan() {
+		expected := strings.TrimRight(scanner.Text(), " \r")
+		if lineNumber >= len(r.output) {
+			return &ComparisonError{Line: lineNumber + 1, Expected: expected, Actual: ""}
+		}
+		actual := strings.TrimRight(r.output[lineNumber], " ")
+		if expected != actual {
+			return &ComparisonError{Line: lineNumber + 1, Expected: expected, Actual: actual}
+		}
+		lineNumber++
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if lineNumber < len(r.output) {
+		return &ComparisonError{Line: lineNumber + 1, Expected: "", Actual: r.output[lineNumber]}
+	}
+	return nil
+}
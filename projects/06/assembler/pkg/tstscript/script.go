@@ -0,0 +1,154 @@
+package tstscript
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type command struct {
+	words []string
+	line  int
+	body  []command
+}
+
+type token struct {
+	text string
+	line int
+}
+
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	line := 1
+	for i := 0; i < len(source); {
+		char := source[i]
+		switch {
+		case char == '\n':
+			line++
+			i++
+		case char == ' ' || char == '\t' || char == '\r':
+			i++
+		case strings.HasPrefix(source[i:], "//"):
+			for i < len(source) && source[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(source[i:], "/*"):
+			end := strings.Index(source[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			line += strings.Count(source[i:i+2+end], "\n")
+			i += end + 4
+		case char == '"':
+			end := strings.IndexByte(source[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated string", line)
+			}
+			tokens = append(tokens, token{text: source[i : i+end+2], line: line})
+			i += end + 2
+		case strings.ContainsRune(",;!{}", rune(char)):
+			tokens = append(tokens, token{text: string(char), line: line})
+			i++
+		default:
+			start := i
+			for i < len(source) && !strings.ContainsRune(" \t\r\n,;!{}\"", rune(source[i])) && !strings.HasPrefix(source[i:], "//") {
+				i++
+			}
+			tokens = append(tokens, token{text: source[start:i], line: line})
+		}
+	}
+	return tokens, nil
+}
+
+func parse(source string) ([]command, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+	commands, rest, err := parseCommands(tokens, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("line %d: unexpected %q", rest[0].line, rest[0].text)
+	}
+	return commands, nil
+}
+
+func parseCommands(tokens []token, isBlock bool) ([]command, []token, error) {
+	var commands []command
+	var current *command
+	for len(tokens) > 0 {
+		tok := tokens[0]
+		tokens = tokens[1:]
+		switch tok.text {
+		case ",", ";", "!":
+			if current != nil {
+				commands = append(commands, *current)
+				current = nil
+			}
+		case "{":
+			if current == nil {
+				return nil, nil, fmt.Errorf("line %d: unexpected \"{\"", tok.line)
+			}
+			body, rest, err := parseCommands(tokens, true)
+			if err != nil {
+				return nil, nil, err
+			}
+			current.body = body
+			commands = append(commands, *current)
+			current = nil
+			tokens = rest
+		case "}":
+			if !isBlock {
+				return nil, nil, fmt.Errorf("line %d: unexpected \"}\"", tok.line)
+			}
+			if current != nil {
+				commands = append(commands, *current)
+			}
+			return commands, tokens, nil
+		default:
+			if current == nil {
+				current = &command{words: []string{}, line: tok.line, body: nil}
+			}
+			current.words = append(current.words, tok.text)
+		}
+	}
+	if isBlock {
+		return nil, nil, fmt.Errorf("missing \"}\" at end of script")
+	}
+	if current != nil {
+		commands = append(commands, *current)
+	}
+	return commands, tokens, nil
+}
+
+func parseValue(text string) (int16, error) {
+	base := 10
+	digits := text
+	if len(text) > 2 && text[0] == '%' {
+		switch text[1] {
+		case 'B':
+			base = 2
+		case 'X':
+			base = 16
+		case 'D':
+			base = 10
+		default:
+			return 0, fmt.Errorf("invalid value %q", text)
+		}
+		digits = text[2:]
+	}
+	if base == 10 {
+		value, err := strconv.ParseInt(digits, 10, 16)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value %q", text)
+		}
+		return int16(value), nil
+	}
+	value, err := strconv.ParseUint(digits, base, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", text)
+	}
+	return int16(value), nil
+}
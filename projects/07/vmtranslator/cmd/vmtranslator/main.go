@@ -1,34 +1,33 @@
 package main
 
 import (
+	"fmt"
+	"log"
 	"os"
-	"strconv"
+	"strings"
 
-	"vmtranslator/pkg/codewriter"
-	"vmtranslator/pkg/parser"
+	"vmtranslator/pkg/translator"
 )
 
 func main() {
+	if len(os.Args) != 2 {
+		log.Fatal("usage: vmtranslator file.vm")
+	}
 	filePath := os.Args[1]
 
-	p := parser.New(filePath)
-	cw := codewriter.New(filePath)
-
-	for p.HasMoreLines() {
-		p.Advance()
-
-		cmdType := p.CommandType()
-
-		if cmdType == parser.CmdArithmetic {
-			cw.WriteArithmetic(p.Arg1())
-		}
-
-		if cmdType == parser.CmdPush || cmdType == parser.CmdPop {
-			index, _ := strconv.Atoi(p.Arg2())
-			cw.WritePushPop(cmdType, p.Arg1(), index)
-		}
+	files, err := translator.LoadFiles(filePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := translator.Options{Bootstrap: translator.BootstrapOff, StackBase: 0, Compact: false, Optimize: false, Comments: false}
+	instructions, err := translator.Translate(files, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 
-	cw.WriteEnd()
-	cw.Close()
+	outputFile := strings.TrimSuffix(filePath, ".vm") + ".asm"
+	if err := os.WriteFile(outputFile, []byte(strings.Join(instructions, "\n")+"\n"), 0644); err != nil {
+		log.Fatal(err)
+	}
 }
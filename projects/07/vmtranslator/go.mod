@@ -1,3 +1,13 @@
-module vmtranslator
+module vmtranslator07
 
 go 1.18
+
+require (
+	assembler v0.0.0
+	vmtranslator v0.0.0
+)
+
+replace (
+	assembler => ../../06/assembler
+	vmtranslator => ../../08/vmtranslator
+)
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"vmtranslator/pkg/peephole"
+)
+
+func main() {
+	outputFile := flag.String("o", "", "write the optimized assembly to this file instead of <name>.opt.asm")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		log.Fatal("usage: asmopt [-o out.asm] file.asm")
+	}
+	filePath := flag.Arg(0)
+
+	lines, err := readLines(filePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	optimized, stats, err := peephole.Optimize(lines)
+	if err != nil {
+		log.Fatalf("%s: %v", filePath, err)
+	}
+
+	if *outputFile == "" {
+		*outputFile = strings.TrimSuffix(filePath, ".asm") + ".opt.asm"
+	}
+	if err := os.WriteFile(*outputFile, []byte(strings.Join(optimized, "\n")+"\n"), 0644); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s: %d instructions before optimization, %d after\n", filePath, stats.Before, stats.After)
+}
+
+func readLines(filePath string) ([]string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
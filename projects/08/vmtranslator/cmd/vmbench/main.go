@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"vmtranslator/pkg/bench"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal("usage: vmbench file.vm|dir...")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(w, "program\t")
+	for _, c := range bench.Configs {
+		fmt.Fprintf(w, "%s\t", c.Name)
+	}
+	fmt.Fprintln(w, "saved\t")
+
+	totals := make([]int, len(bench.Configs))
+	for _, path := range os.Args[1:] {
+		counts := make([]int, len(bench.Configs))
+		for i, c := range bench.Configs {
+			count, err := bench.CountInstructions(path, c)
+			if err != nil {
+				log.Fatal(err)
+			}
+			counts[i] = count
+			totals[i] += count
+		}
+		writeRow(w, filepath.Base(filepath.Clean(path)), counts)
+	}
+	if len(os.Args) > 2 {
+		writeRow(w, "total", totals)
+	}
+	w.Flush()
+}
+
+// The saved column compares the plain translation with optimized+peephole,
+// the smallest output that still inlines call, return and the comparisons.
+func writeRow(w *tabwriter.Writer, name string, counts []int) {
+	fmt.Fprintf(w, "%s\t", name)
+	for _, count := range counts {
+		fmt.Fprintf(w, "%d\t", count)
+	}
+	saved := 0.0
+	if counts[0] > 0 {
+		saved = 100 * float64(counts[0]-counts[2]) / float64(counts[0])
+	}
+	fmt.Fprintf(w, "%.1f%%\t\n", saved)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"vmtranslator/pkg/parser"
+	"vmtranslator/pkg/vmemu"
+)
+
+func main() {
+	steps := flag.Int("steps", 100000, "maximum number of VM commands to execute")
+	bootstrap := flag.Bool("bootstrap", false, "set SP to 256 and call Sys.init before running")
+	ramFlag := flag.String("ram", "0-4", "comma separated RAM addresses or ranges to dump, e.g. 0,1,256-260")
+	setFlag := flag.String("set", "", "comma separated RAM initialisations, e.g. 0=256,1=300")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		log.Fatal("usage: vmemu [-steps n] [-bootstrap] [-ram addresses] [-set addr=value,...] file.vm|dir")
+	}
+
+	e := vmemu.New()
+	files, err := parser.ListFiles(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, path := range files {
+		if err := e.LoadFile(path); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *setFlag != "" {
+		for _, part := range strings.Split(*setFlag, ",") {
+			addrStr, valueStr, _ := strings.Cut(part, "=")
+			addr := parseAddress(addrStr)
+			value, err := strconv.ParseInt(strings.TrimSpace(valueStr), 10, 16)
+			if err != nil {
+				log.Fatalf("invalid RAM value %q", valueStr)
+			}
+			e.SetRAM(addr, int16(value))
+		}
+	}
+
+	if *bootstrap {
+		if err := e.Bootstrap(); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := e.Run(*steps); err != nil {
+		log.Fatalf("step %d: %v", e.Steps(), err)
+	}
+
+	fmt.Printf("steps=%d function=%s command=%q\n", e.Steps(), e.CurrentFunction(), e.CurrentCommand())
+	for _, frame := range e.CallStack() {
+		fmt.Printf("  in %s\n", frame.Function)
+	}
+	for _, part := range strings.Split(*ramFlag, ",") {
+		fromStr, toStr, isRange := strings.Cut(part, "-")
+		from := parseAddress(fromStr)
+		to := from
+		if isRange {
+			to = parseAddress(toStr)
+		}
+		for addr := from; addr <= to; addr++ {
+			fmt.Printf("RAM[%d] = %d\n", addr, e.RAM(addr))
+		}
+	}
+}
+
+func parseAddress(value string) int {
+	addr, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || addr < 0 || addr >= vmemu.MemorySize {
+		log.Fatalf("invalid RAM address %q", value)
+	}
+	return addr
+}
@@ -1,59 +1,85 @@
 package main
 
 import (
-	"io/fs"
+	"flag"
+	"fmt"
+	"log"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 
+	"assembler/pkg/assembler"
 	"vmtranslator/pkg/codewriter"
-	"vmtranslator/pkg/parser"
+	"vmtranslator/pkg/peephole"
+	"vmtranslator/pkg/sourcemap"
+	"vmtranslator/pkg/translator"
 )
 
 func main() {
-	filePath := os.Args[1]
-
-	cw := codewriter.New(filePath)
-
-	dir := filepath.Dir(filePath)
-	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
-		if filepath.Ext(path) == ".vm" {
-			fileName := strings.Split(filepath.Base(path), ".")[0]
-			cw.SetFileName(fileName)
-
-			p := parser.New(path)
-			for p.HasMoreLines() {
-				p.Advance()
-
-				cmdType := p.CommandType()
-
-				if cmdType == parser.CmdArithmetic {
-					cw.WriteArithmetic(p.Arg1())
-				} else if cmdType == parser.CmdPush || cmdType == parser.CmdPop {
-					index, _ := strconv.Atoi(p.Arg2())
-					cw.WritePushPop(cmdType, p.Arg1(), index)
-				} else if cmdType == parser.CmdLabel {
-					cw.WriteLabel(p.Arg1())
-				} else if cmdType == parser.CmdIf {
-					cw.WriteIf(p.Arg1())
-				} else if cmdType == parser.CmdGoto {
-					cw.WriteGoto(p.Arg1())
-				} else if cmdType == parser.CmdFunction {
-					nArgs, _ := strconv.Atoi(p.Arg2())
-					cw.WriteFunction(p.Arg1(), nArgs)
-				} else if cmdType == parser.CmdCall {
-					nArgs, _ := strconv.Atoi(p.Arg2())
-					cw.WriteCall(p.Arg1(), nArgs)
-				} else if cmdType == parser.CmdReturn {
-					cw.WriteReturn()
-				}
-			}
+	optimize := flag.Bool("O", false, "generate specialised push, pop and arithmetic code and run the peephole optimizer over it")
+	compact := flag.Bool("compact", false, "share one copy of the call, return and comparison code instead of inlining it")
+	bootstrap := flag.Bool("bootstrap", false, "always emit the bootstrap code, even if no Sys.init is defined")
+	stackBase := flag.Int("stack", codewriter.DefaultStackBase, "initial stack pointer set by the bootstrap code")
+	comments := flag.Bool("comments", false, "precede each command's assembly with a // File.vm:line: command comment")
+	writeMap := flag.Bool("map", false, "also assemble the output and write a <out>.map from ROM addresses to VM commands")
+	outputFile := flag.String("o", "", "output file (default: <dir>/<dir>.asm for a directory, <name>.asm for a file)")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		log.Fatal("usage: vmtranslator [-O] [-compact] [-bootstrap] [-stack n] [-comments] [-map] [-o out.asm] file.vm|dir")
+	}
+	inputPath := filepath.Clean(flag.Arg(0))
 
+	files, err := translator.LoadFiles(inputPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := translator.Options{Bootstrap: translator.BootstrapAuto, StackBase: *stackBase, Compact: *compact, Optimize: *optimize, Comments: *comments || *writeMap}
+	if *bootstrap {
+		opts.Bootstrap = translator.BootstrapOn
+	}
+	instructions, err := translator.Translate(files, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *optimize {
+		optimized, stats, err := peephole.Optimize(instructions)
+		if err != nil {
+			log.Fatal(err)
+		}
+		instructions = optimized
+		fmt.Printf("%d instructions before optimization, %d after\n", stats.Before, stats.After)
+	}
+
+	if *outputFile == "" {
+		*outputFile = defaultOutputPath(inputPath)
+	}
+	source := strings.Join(instructions, "\n") + "\n"
+	if err := os.WriteFile(*outputFile, []byte(source), 0644); err != nil {
+		log.Fatal(err)
+	}
+
+	if *writeMap {
+		program, err := assembler.Assemble(strings.NewReader(source), assembler.Options{Filename: *outputFile})
+		if err != nil {
+			log.Fatal(err)
+		}
+		var sb strings.Builder
+		sourcemap.Write(&sb, sourcemap.Build(instructions, program))
+		if err := os.WriteFile(strings.TrimSuffix(*outputFile, ".asm")+".map", []byte(sb.String()), 0644); err != nil {
+			log.Fatal(err)
 		}
-		return nil
-	})
+	}
+}
 
-	cw.WriteEnd()
-	cw.Close()
+func defaultOutputPath(inputPath string) string {
+	if filepath.Ext(inputPath) == ".vm" {
+		return strings.TrimSuffix(inputPath, ".vm") + ".asm"
+	}
+	dir, err := filepath.Abs(inputPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return filepath.Join(inputPath, filepath.Base(dir)+".asm")
 }
@@ -1,3 +1,7 @@
 module vmtranslator
 
 go 1.18
+
+require assembler v0.0.0
+
+replace assembler => ../../06/assembler
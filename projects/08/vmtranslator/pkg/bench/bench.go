@@ -0,0 +1,43 @@
+package bench
+
+import (
+	"vmtranslator/pkg/peephole"
+	"vmtranslator/pkg/translator"
+)
+
+type Config struct {
+	Name     string
+	Compact  bool
+	Optimize bool
+	Peephole bool
+}
+
+// Configs lists the translation modes compared by cmd/vmbench and the
+// codewriter benchmark, starting with the plain translation.
+var Configs = []Config{
+	{Name: "plain", Compact: false, Optimize: false, Peephole: false},
+	{Name: "optimized", Compact: false, Optimize: true, Peephole: false},
+	{Name: "optimized+peephole", Compact: false, Optimize: true, Peephole: true},
+	{Name: "compact", Compact: true, Optimize: false, Peephole: false},
+	{Name: "compact+optimized+peephole", Compact: true, Optimize: true, Peephole: true},
+}
+
+func CountInstructions(path string, c Config) (int, error) {
+	files, err := translator.LoadFiles(path)
+	if err != nil {
+		return 0, err
+	}
+	opts := translator.Options{Bootstrap: translator.BootstrapAuto, StackBase: 0, Compact: c.Compact, Optimize: c.Optimize, Comments: false}
+	instructions, err := translator.Translate(files, opts)
+	if err != nil {
+		return 0, err
+	}
+	if !c.Peephole {
+		return peephole.Count(instructions), nil
+	}
+	_, stats, err := peephole.Optimize(instructions)
+	if err != nil {
+		return 0, err
+	}
+	return stats.After, nil
+}
@@ -1,67 +1,89 @@
 package codewriter
 
 import (
-	"log"
-	"os"
-	"path/filepath"
 	"strconv"
+	"strings"
 	"vmtranslator/pkg/parser"
 )
 
+const DefaultStackBase = 256
+
 type CodeWriter struct {
-	file              *os.File
 	fileName          string
 	uniqueLabelIndex  int
 	functionCallIndex int
+	instructions      []string
+	compact           bool
+	optimize          bool
+	stackBase         int
+	currentFunction   string
 }
 
-func New(filePath string) *CodeWriter {
-	dir := filepath.Dir(filePath)
-	dirName := filepath.Base(dir)
-	f, err := os.Create(filepath.Join(dir, dirName+".asm"))
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	cw := &CodeWriter{
-		file:              f,
+func New() *CodeWriter {
+	return &CodeWriter{
 		fileName:          "",
 		uniqueLabelIndex:  0,
 		functionCallIndex: 0,
+		instructions:      nil,
+		compact:           false,
+		optimize:          false,
+		stackBase:         DefaultStackBase,
+		currentFunction:   "",
 	}
-	cw.writeBootstrap()
-	return cw
 }
 
 func (cw *CodeWriter) SetFileName(fileName string) {
 	cw.fileName = fileName
+	cw.currentFunction = ""
+}
+
+func (cw *CodeWriter) SetCompact(compact bool) {
+	cw.compact = compact
 }
 
-func (cw *CodeWriter) Close() {
-	cw.file.Sync()
-	cw.file.Close()
+func (cw *CodeWriter) SetOptimize(optimize bool) {
+	cw.optimize = optimize
 }
 
-func (cw *CodeWriter) writeBootstrap() {
+func (cw *CodeWriter) SetStackBase(stackBase int) {
+	cw.stackBase = stackBase
+}
+
+func (cw *CodeWriter) Instructions() []string {
+	return cw.instructions
+}
+
+func (cw *CodeWriter) WriteBootstrap() {
 	ab := newAsmBuilder()
-	ab.Add("@256")
+	ab.Add("@" + strconv.Itoa(cw.stackBase))
 	ab.Add("D=A")
 	ab.Add("@SP")
 	ab.Add("M=D")
-	cw.writeToFile(ab.Instructions()...)
+	cw.write(ab.Instructions()...)
 
 	cw.WriteCall("Sys.init", 0)
 }
 
+func (cw *CodeWriter) WriteComment(comment string) {
+	cw.write("// " + comment)
+}
+
 func (cw *CodeWriter) WriteEnd() {
-	cw.writeToFile(
+	cw.write(
 		"(END)",
 		"@END",
 		"0;JMP",
 	)
+	if cw.compact {
+		cw.writeRoutines()
+	}
 }
 
 func (cw *CodeWriter) WriteArithmetic(command string) {
+	if cw.optimize && cw.writeOptimizedArithmetic(command) {
+		return
+	}
+
 	ab := newAsmBuilder()
 
 	switch command {
@@ -81,12 +103,13 @@ func (cw *CodeWriter) WriteArithmetic(command string) {
 		ab.Add(popStack()...)
 		ab.Add("D=-M")
 		ab.Add(pushDRegToStack()...)
-	case "eq":
-		ab.Add(cw.compare("JEQ")...)
-	case "gt":
-		ab.Add(cw.compare("JGT")...)
-	case "lt":
-		ab.Add(cw.compare("JLT")...)
+	case "eq", "gt", "lt":
+		if cw.compact {
+			ab.Add(cw.callRoutine("$$"+strings.ToUpper(command), "R15")...)
+		} else {
+			index := cw.getNextUniqueLabelIndex()
+			ab.Add(compare("J"+strings.ToUpper(command), "ON_TRUE_"+index, "END_"+index)...)
+		}
 	case "and":
 		ab.Add(popStack()...)
 		ab.Add("D=M")
@@ -105,14 +128,10 @@ func (cw *CodeWriter) WriteArithmetic(command string) {
 		ab.Add(pushDRegToStack()...)
 	}
 
-	cw.writeToFile(ab.Instructions()...)
+	cw.write(ab.Instructions()...)
 }
 
-func (cw *CodeWriter) compare(jumpInstruction string) []string {
-	index := cw.getNextUniqueLabelIndex()
-	onTrueLabel := "ON_TRUE_" + index
-	endLabel := "END_" + index
-
+func compare(jumpInstruction, onTrueLabel, endLabel string) []string {
 	ab := newAsmBuilder()
 	ab.Add(popStack()...)
 	ab.Add("D=M")
@@ -137,7 +156,11 @@ func (cw *CodeWriter) getNextUniqueLabelIndex() string {
 }
 
 func (cw *CodeWriter) WritePushPop(cmdType parser.CmdType, segment string, index int) {
-	if cmdType == parser.CmdPush {
+	if cw.optimize && cmdType == parser.CmdPush {
+		cw.writeOptimizedPush(segment, index)
+	} else if cw.optimize && cmdType == parser.CmdPop {
+		cw.writeOptimizedPop(segment, index)
+	} else if cmdType == parser.CmdPush {
 		cw.writePush(segment, index)
 	} else if cmdType == parser.CmdPop {
 		cw.writePop(segment, index)
@@ -148,19 +171,14 @@ func (cw *CodeWriter) writePop(segment string, index int) {
 	ab := newAsmBuilder()
 	segmentAddress := cw.getSegmentAddress(segment, index)
 
-	if segment == "temp" || segment == "pointer" {
+	if segment == "temp" || segment == "pointer" || segment == "static" {
 		ab.Add(popStack()...)
 		ab.Add("D=M")
 		ab.Add("@" + segmentAddress)
 		ab.Add("M=D")
 	} else {
-		if segment == "static" {
-			ab.Add("@" + segmentAddress)
-			ab.Add("D=A")
-		} else {
-			ab.Add("@" + segmentAddress)
-			ab.Add("D=M")
-		}
+		ab.Add("@" + segmentAddress)
+		ab.Add("D=M")
 		ab.Add("@" + strconv.Itoa(index))
 		ab.Add("D=D+A")
 		ab.Add("@R13")
@@ -172,7 +190,7 @@ func (cw *CodeWriter) writePop(segment string, index int) {
 		ab.Add("M=D")
 	}
 
-	cw.writeToFile(ab.Instructions()...)
+	cw.write(ab.Instructions()...)
 }
 
 func (cw *CodeWriter) writePush(segment string, index int) {
@@ -192,7 +210,7 @@ func (cw *CodeWriter) writePush(segment string, index int) {
 	}
 	ab.Add(pushDRegToStack()...)
 
-	cw.writeToFile(ab.Instructions()...)
+	cw.write(ab.Instructions()...)
 }
 
 func (cw *CodeWriter) getSegmentAddress(segment string, index int) string {
@@ -222,8 +240,15 @@ func (cw *CodeWriter) getSegmentAddress(segment string, index int) string {
 	}
 }
 
+func (cw *CodeWriter) scopedLabel(label string) string {
+	if cw.currentFunction == "" {
+		return cw.fileName + "$" + label
+	}
+	return cw.currentFunction + "$" + label
+}
+
 func (cw *CodeWriter) WriteLabel(label string) {
-	cw.writeToFile("(" + label + ")")
+	cw.write("(" + cw.scopedLabel(label) + ")")
 }
 
 func (cw *CodeWriter) WriteIf(label string) {
@@ -231,31 +256,33 @@ func (cw *CodeWriter) WriteIf(label string) {
 
 	ab.Add(popStack()...)
 	ab.Add("D=M")
-	ab.Add("@" + label)
+	ab.Add("@" + cw.scopedLabel(label))
 	ab.Add("D;JNE")
 
-	cw.writeToFile(ab.Instructions()...)
+	cw.write(ab.Instructions()...)
 }
 
 func (cw *CodeWriter) WriteGoto(label string) {
 	ab := newAsmBuilder()
 
-	ab.Add("@" + label)
+	ab.Add("@" + cw.scopedLabel(label))
 	ab.Add("0;JMP")
 
-	cw.writeToFile(ab.Instructions()...)
+	cw.write(ab.Instructions()...)
 }
 
 func (cw *CodeWriter) WriteFunction(functionName string, nVars int) {
 	ab := newAsmBuilder()
 
+	cw.currentFunction = functionName
+
 	ab.Add("(" + functionName + ")")
 	ab.Add("D=0")
 	for i := 0; i < nVars; i++ {
 		ab.Add(pushDRegToStack()...)
 	}
 
-	cw.writeToFile(ab.Instructions()...)
+	cw.write(ab.Instructions()...)
 }
 
 func (cw *CodeWriter) WriteCall(functionName string, nVars int) {
@@ -263,6 +290,24 @@ func (cw *CodeWriter) WriteCall(functionName string, nVars int) {
 	retLabel := functionName + "$ret." + strconv.Itoa(cw.functionCallIndex)
 	cw.functionCallIndex++
 
+	if cw.compact {
+		ab.Add("@" + strconv.Itoa(nVars))
+		ab.Add("D=A")
+		ab.Add("@R13")
+		ab.Add("M=D")
+		ab.Add("@" + functionName)
+		ab.Add("D=A")
+		ab.Add("@R14")
+		ab.Add("M=D")
+		ab.Add("@" + retLabel)
+		ab.Add("D=A")
+		ab.Add("@$$CALL")
+		ab.Add("0;JMP")
+		ab.Add("(" + retLabel + ")")
+		cw.write(ab.Instructions()...)
+		return
+	}
+
 	ab.Add("@" + retLabel)
 	ab.Add("D=A")
 	ab.Add(pushDRegToStack()...)
@@ -290,10 +335,18 @@ func (cw *CodeWriter) WriteCall(functionName string, nVars int) {
 
 	ab.Add("(" + retLabel + ")")
 
-	cw.writeToFile(ab.Instructions()...)
+	cw.write(ab.Instructions()...)
 }
 
 func (cw *CodeWriter) WriteReturn() {
+	if cw.compact {
+		cw.write("@$$RETURN", "0;JMP")
+		return
+	}
+	cw.write(returnFrame()...)
+}
+
+func returnFrame() []string {
 	ab := newAsmBuilder()
 
 	ab.Add("@LCL")
@@ -323,7 +376,7 @@ func (cw *CodeWriter) WriteReturn() {
 	ab.Add("A=M")
 	ab.Add("0;JMP")
 
-	cw.writeToFile(ab.Instructions()...)
+	return ab.Instructions()
 }
 
 func setSegmentAddressToFrameOffset(segment, frame string, offset int) []string {
@@ -360,8 +413,6 @@ func pushDRegToStack() []string {
 	}
 }
 
-func (cw *CodeWriter) writeToFile(assembly ...string) {
-	for _, asmInstruction := range assembly {
-		cw.file.WriteString(asmInstruction + "\n")
-	}
+func (cw *CodeWriter) write(assembly ...string) {
+	cw.instructions = append(cw.instructions, assembly...)
 }
@@ -0,0 +1,260 @@
+package codewriter
+
+import (
+	"strconv"
+	"vmtranslator/pkg/parser"
+)
+
+// With optimization enabled push and pop address small segment offsets
+// directly, constants 0, 1 and -1 are written without loading them into D,
+// and a push followed by a pop or an add, sub, and, or, neg or not is
+// translated as one unit that never stores the pushed value on the stack.
+
+// A pop steps A forward from the segment base up to maxInlineOffset times
+// before falling back to computing the address in R13.
+const maxInlineOffset = 6
+
+var binaryComps = map[string]string{
+	"add": "D+M",
+	"sub": "M-D",
+	"and": "D&M",
+	"or":  "D|M",
+}
+
+var unaryOps = map[string]string{
+	"neg": "-",
+	"not": "!",
+}
+
+func CanFuse(first, second parser.Command) bool {
+	if first.Type != parser.CmdPush {
+		return false
+	}
+	switch second.Type {
+	case parser.CmdPop:
+		return true
+	case parser.CmdArithmetic:
+		_, binary := binaryComps[second.Arg1]
+		_, unary := unaryOps[second.Arg1]
+		return binary || unary
+	}
+	return false
+}
+
+func (cw *CodeWriter) WriteFused(first, second parser.Command) {
+	if second.Type == parser.CmdPop {
+		cw.writeMove(first.Arg1, first.Arg2, second.Arg1, second.Arg2)
+	} else if op, found := unaryOps[second.Arg1]; found {
+		cw.writeUnaryPush(first.Arg1, first.Arg2, op)
+	} else {
+		cw.writeBinaryPush(first.Arg1, first.Arg2, second.Arg1)
+	}
+}
+
+func (cw *CodeWriter) writeOptimizedPush(segment string, index int) {
+	cw.writeUnaryPush(segment, index, "")
+}
+
+func (cw *CodeWriter) writeOptimizedPop(segment string, index int) {
+	ab := newAsmBuilder()
+
+	if target, found := cw.segmentTarget(segment, index); found {
+		ab.Add("@SP")
+		ab.Add("AM=M-1")
+		ab.Add("D=M")
+		ab.Add(target...)
+		ab.Add("M=D")
+	} else {
+		ab.Add(cw.addressToR13(segment, index)...)
+		ab.Add("@SP")
+		ab.Add("AM=M-1")
+		ab.Add("D=M")
+		ab.Add("@R13")
+		ab.Add("A=M")
+		ab.Add("M=D")
+	}
+
+	cw.write(ab.Instructions()...)
+}
+
+func (cw *CodeWriter) writeOptimizedArithmetic(command string) bool {
+	ab := newAsmBuilder()
+
+	if comp, found := binaryComps[command]; found {
+		ab.Add("@SP")
+		ab.Add("AM=M-1")
+		ab.Add("D=M")
+		ab.Add("A=A-1")
+		ab.Add("M=" + comp)
+	} else if op, found := unaryOps[command]; found {
+		ab.Add("@SP")
+		ab.Add("A=M-1")
+		ab.Add("M=" + op + "M")
+	} else {
+		return false
+	}
+
+	cw.write(ab.Instructions()...)
+	return true
+}
+
+// writeMove translates push source; pop target.
+func (cw *CodeWriter) writeMove(srcSegment string, srcIndex int, dstSegment string, dstIndex int) {
+	ab := newAsmBuilder()
+
+	if comp, found := constantComp(srcSegment, srcIndex, ""); found {
+		if target, found := cw.segmentTarget(dstSegment, dstIndex); found {
+			ab.Add(target...)
+		} else {
+			ab.Add("@" + cw.getSegmentAddress(dstSegment, dstIndex))
+			ab.Add("D=M")
+			ab.Add("@" + strconv.Itoa(dstIndex))
+			ab.Add("A=D+A")
+		}
+		ab.Add("M=" + comp)
+	} else if target, found := cw.segmentTarget(dstSegment, dstIndex); found {
+		ab.Add(cw.loadD(srcSegment, srcIndex, "")...)
+		ab.Add(target...)
+		ab.Add("M=D")
+	} else {
+		ab.Add(cw.addressToR13(dstSegment, dstIndex)...)
+		ab.Add(cw.loadD(srcSegment, srcIndex, "")...)
+		ab.Add("@R13")
+		ab.Add("A=M")
+		ab.Add("M=D")
+	}
+
+	cw.write(ab.Instructions()...)
+}
+
+// writeUnaryPush pushes op applied to segment[index], where op is "", "-"
+// or "!".
+func (cw *CodeWriter) writeUnaryPush(segment string, index int, op string) {
+	ab := newAsmBuilder()
+
+	comp, found := constantComp(segment, index, op)
+	if !found {
+		ab.Add(cw.loadD(segment, index, op)...)
+		comp = "D"
+	}
+	ab.Add("@SP")
+	ab.Add("M=M+1")
+	ab.Add("A=M-1")
+	ab.Add("M=" + comp)
+
+	cw.write(ab.Instructions()...)
+}
+
+// writeBinaryPush translates push segment index followed by a binary
+// command, applying it to the top of the stack in place.
+func (cw *CodeWriter) writeBinaryPush(segment string, index int, command string) {
+	ab := newAsmBuilder()
+
+	comp, found := binaryConstantComp(segment, index, command)
+	if found && comp == "M" {
+		return
+	}
+	if !found {
+		ab.Add(cw.loadD(segment, index, "")...)
+		comp = binaryComps[command]
+	}
+	ab.Add("@SP")
+	ab.Add("A=M-1")
+	ab.Add("M=" + comp)
+
+	cw.write(ab.Instructions()...)
+}
+
+// binaryConstantComp returns the comp field that applies command with a
+// constant 0 or 1 operand to M without going through D.
+func binaryConstantComp(segment string, index int, command string) (string, bool) {
+	if segment != "constant" {
+		return "", false
+	}
+	switch {
+	case index == 0 && command == "and":
+		return "0", true
+	case index == 0:
+		return "M", true
+	case index == 1 && command == "add":
+		return "M+1", true
+	case index == 1 && command == "sub":
+		return "M-1", true
+	}
+	return "", false
+}
+
+// constantComp returns the comp field for op applied to a constant when
+// the result is 0, 1 or -1.
+func constantComp(segment string, index int, op string) (string, bool) {
+	if segment != "constant" {
+		return "", false
+	}
+	value := index
+	switch op {
+	case "-":
+		value = -index
+	case "!":
+		value = ^index
+	}
+	switch value {
+	case 0, 1, -1:
+		return strconv.Itoa(value), true
+	}
+	return "", false
+}
+
+// loadD sets D to op applied to segment[index].
+func (cw *CodeWriter) loadD(segment string, index int, op string) []string {
+	if comp, found := constantComp(segment, index, op); found {
+		return []string{"D=" + comp}
+	}
+
+	segmentAddress := cw.getSegmentAddress(segment, index)
+	switch segment {
+	case "constant":
+		return []string{"@" + segmentAddress, "D=" + op + "A"}
+	case "temp", "pointer", "static":
+		return []string{"@" + segmentAddress, "D=" + op + "M"}
+	}
+	if index <= 1 {
+		target, _ := cw.segmentTarget(segment, index)
+		return append(target, "D="+op+"M")
+	}
+	return []string{
+		"@" + segmentAddress,
+		"D=M",
+		"@" + strconv.Itoa(index),
+		"A=D+A",
+		"D=" + op + "M",
+	}
+}
+
+// segmentTarget points A at segment[index] without touching D.
+func (cw *CodeWriter) segmentTarget(segment string, index int) ([]string, bool) {
+	segmentAddress := cw.getSegmentAddress(segment, index)
+	switch segment {
+	case "temp", "pointer", "static":
+		return []string{"@" + segmentAddress}, true
+	}
+	if index > maxInlineOffset {
+		return nil, false
+	}
+
+	target := []string{"@" + segmentAddress, "A=M"}
+	for i := 0; i < index; i++ {
+		target = append(target, "A=A+1")
+	}
+	return target, true
+}
+
+func (cw *CodeWriter) addressToR13(segment string, index int) []string {
+	return []string{
+		"@" + cw.getSegmentAddress(segment, index),
+		"D=M",
+		"@" + strconv.Itoa(index),
+		"D=D+A",
+		"@R13",
+		"M=D",
+	}
+}
@@ -0,0 +1,99 @@
+package codewriter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"vmtranslator/pkg/parser"
+)
+
+func parseCommands(t *testing.T, source string) []parser.Command {
+	t.Helper()
+	commands, err := parser.Parse(strings.NewReader(source), "Test.vm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return commands
+}
+
+func TestWriteFused(t *testing.T) {
+	tests := []struct {
+		source string
+		want   []string
+	}{
+		{"push constant 0\nadd", nil},
+		{"push constant 0\nsub", nil},
+		{"push constant 0\nor", nil},
+		{"push constant 0\nand", []string{"@SP", "A=M-1", "M=0"}},
+		{"push constant 1\nadd", []string{"@SP", "A=M-1", "M=M+1"}},
+		{"push constant 1\nsub", []string{"@SP", "A=M-1", "M=M-1"}},
+		{"push constant 1\nand", []string{"D=1", "@SP", "A=M-1", "M=D&M"}},
+		{"push constant 1\nneg", []string{"@SP", "M=M+1", "A=M-1", "M=-1"}},
+		{"push constant 0\nnot", []string{"@SP", "M=M+1", "A=M-1", "M=-1"}},
+		{"push constant 7\nneg", []string{"@7", "D=-A", "@SP", "M=M+1", "A=M-1", "M=D"}},
+		{"push local 2\nadd", []string{"@LCL", "D=M", "@2", "A=D+A", "D=M", "@SP", "A=M-1", "M=D+M"}},
+		{"push argument 1\npop static 2", []string{"@ARG", "A=M", "A=A+1", "D=M", "@Test.2", "M=D"}},
+		{"push constant 0\npop local 3", []string{"@LCL", "A=M", "A=A+1", "A=A+1", "A=A+1", "M=0"}},
+		{"push constant 1\npop that 9", []string{"@THAT", "D=M", "@9", "A=D+A", "M=1"}},
+		{"push temp 1\npop that 7", []string{"@THAT", "D=M", "@7", "D=D+A", "@R13", "M=D", "@R6", "D=M", "@R13", "A=M", "M=D"}},
+	}
+	for _, tt := range tests {
+		t.Run(strings.ReplaceAll(tt.source, "\n", "; "), func(t *testing.T) {
+			commands := parseCommands(t, tt.source)
+			if !CanFuse(commands[0], commands[1]) {
+				t.Fatal("CanFuse = false")
+			}
+			cw := New()
+			cw.SetFileName("Test")
+			cw.SetOptimize(true)
+			cw.WriteFused(commands[0], commands[1])
+			if got := cw.Instructions(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanFuse(t *testing.T) {
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{"push local 0\npop local 1", true},
+		{"push local 0\nneg", true},
+		{"push local 0\neq", false},
+		{"push local 0\npush local 1", false},
+		{"pop local 0\nadd", false},
+	}
+	for _, tt := range tests {
+		commands := parseCommands(t, tt.source)
+		if got := CanFuse(commands[0], commands[1]); got != tt.want {
+			t.Errorf("CanFuse(%q) = %v, want %v", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestWriteOptimizedPushPop(t *testing.T) {
+	pop := []string{"@SP", "AM=M-1", "D=M"}
+	tests := []struct {
+		cmdType parser.CmdType
+		segment string
+		index   int
+		want    []string
+	}{
+		{parser.CmdPush, "constant", 1, []string{"@SP", "M=M+1", "A=M-1", "M=1"}},
+		{parser.CmdPush, "local", 0, []string{"@LCL", "A=M", "D=M", "@SP", "M=M+1", "A=M-1", "M=D"}},
+		{parser.CmdPop, "temp", 2, append(pop, "@R7", "M=D")},
+		{parser.CmdPop, "local", maxInlineOffset, append(pop, "@LCL", "A=M", "A=A+1", "A=A+1", "A=A+1", "A=A+1", "A=A+1", "A=A+1", "M=D")},
+		{parser.CmdPop, "local", maxInlineOffset + 1, []string{"@LCL", "D=M", "@7", "D=D+A", "@R13", "M=D", "@SP", "AM=M-1", "D=M", "@R13", "A=M", "M=D"}},
+	}
+	for _, tt := range tests {
+		cw := New()
+		cw.SetOptimize(true)
+		cw.WritePushPop(tt.cmdType, tt.segment, tt.index)
+		if got := cw.Instructions(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v %s %d: got %q, want %q", tt.cmdType, tt.segment, tt.index, got, tt.want)
+		}
+	}
+}
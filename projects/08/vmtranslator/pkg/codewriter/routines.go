@@ -0,0 +1,58 @@
+package codewriter
+
+// In compact mode call, return and the comparisons jump into one shared copy
+// of their code: R13 holds nArgs, R14 the callee and R15 the comparison's
+// return address, while $$CALL receives its return address in D.
+
+func (cw *CodeWriter) callRoutine(routine, returnRegister string) []string {
+	retLabel := "RET_" + cw.getNextUniqueLabelIndex()
+
+	ab := newAsmBuilder()
+	ab.Add("@" + retLabel)
+	ab.Add("D=A")
+	ab.Add("@" + returnRegister)
+	ab.Add("M=D")
+	ab.Add("@" + routine)
+	ab.Add("0;JMP")
+	ab.Add("(" + retLabel + ")")
+	return ab.Instructions()
+}
+
+func (cw *CodeWriter) writeRoutines() {
+	ab := newAsmBuilder()
+
+	ab.Add("($$CALL)")
+	ab.Add(pushDRegToStack()...)
+	ab.Add(pushAddressToStack("LCL")...)
+	ab.Add(pushAddressToStack("ARG")...)
+	ab.Add(pushAddressToStack("THIS")...)
+	ab.Add(pushAddressToStack("THAT")...)
+	ab.Add("@R13")
+	ab.Add("D=M")
+	ab.Add("@5")
+	ab.Add("D=D+A")
+	ab.Add("@SP")
+	ab.Add("D=M-D")
+	ab.Add("@ARG")
+	ab.Add("M=D")
+	ab.Add("@SP")
+	ab.Add("D=M")
+	ab.Add("@LCL")
+	ab.Add("M=D")
+	ab.Add("@R14")
+	ab.Add("A=M")
+	ab.Add("0;JMP")
+
+	ab.Add("($$RETURN)")
+	ab.Add(returnFrame()...)
+
+	for _, command := range []string{"EQ", "GT", "LT"} {
+		ab.Add("($$" + command + ")")
+		ab.Add(compare("J"+command, "$$"+command+"_TRUE", "$$"+command+"_END")...)
+		ab.Add("@R15")
+		ab.Add("A=M")
+		ab.Add("0;JMP")
+	}
+
+	cw.write(ab.Instructions()...)
+}
@@ -0,0 +1,110 @@
+package codewriter_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"assembler/pkg/assembler"
+	"assembler/pkg/hackcpu"
+	"vmtranslator/pkg/bench"
+	"vmtranslator/pkg/translator"
+)
+
+// Each program leaves values on the stack and in the segments, so running it
+// with and without optimization must leave the same RAM behind.
+var optimizedPrograms = []string{
+	"push constant 5\npush constant 0\nadd\npush constant 0\nsub\npush constant 0\nor\n",
+	"push constant 5\npush constant 0\nand\n",
+	"push constant 5\npush constant 1\nadd\npush constant 1\nsub\npush constant 1\nsub\n",
+	"push constant 7\npush constant 1\nand\npush constant 6\npush constant 1\nor\n",
+	"push constant 1\nneg\npush constant 0\nnot\npush constant 7\nneg\npush constant 7\nnot\npush constant 0\nneg\n",
+	"push local 2\npush argument 1\nsub\npush that 8\nand\npush this 1\nor\nneg\npush static 3\nadd\nnot\n",
+	"push constant 0\npop local 3\npush constant 1\npop that 9\npush temp 1\npop that 7\npush argument 1\npop static 2\npush local 8\npop argument 0\n",
+	"push constant 9\npush constant 8\npop local 6\npop local 7\npush pointer 0\npop temp 0\npush that 0\npop this 12\n",
+	"push local 0\npush local 1\nadd\npush local 9\nsub\npop local 10\npush local 10\npush constant 3\nlt\npop temp 7\n",
+}
+
+var segmentBases = map[int]int16{0: 256, 1: 300, 2: 400, 3: 3000, 4: 3010}
+
+func runProgram(t *testing.T, source string, opts translator.Options) *hackcpu.CPU {
+	t.Helper()
+	instructions, err := translator.Translate([]translator.VMFile{{Name: "Test.vm", Source: strings.NewReader(source)}}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	program, err := assembler.Assemble(strings.NewReader(strings.Join(instructions, "\n")), assembler.Options{Filename: "Test.asm"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cpu := hackcpu.New()
+	cpu.LoadProgram(program.Words())
+	for address := 5; address < 3100; address++ {
+		cpu.SetRAM(address, int16(address*7%101))
+	}
+	for address, base := range segmentBases {
+		cpu.SetRAM(address, base)
+	}
+	if err := cpu.Run(2000); err != nil {
+		t.Fatal(err)
+	}
+	return cpu
+}
+
+func TestOptimizedMatchesPlain(t *testing.T) {
+	for _, source := range optimizedPrograms {
+		plain := runProgram(t, source, translator.Options{Bootstrap: translator.BootstrapOff, StackBase: 0, Compact: false, Optimize: false, Comments: false})
+		optimized := runProgram(t, source, translator.Options{Bootstrap: translator.BootstrapOff, StackBase: 0, Compact: false, Optimize: true, Comments: false})
+
+		sp := int(plain.RAM(0))
+		var addresses []int
+		for address := 0; address < 3100; address++ {
+			// R13-R15 are scratch, and the stack above SP holds dead values.
+			if address >= 13 && address <= 15 || address >= sp && address < 300 {
+				continue
+			}
+			addresses = append(addresses, address)
+		}
+		for _, address := range addresses {
+			if got, want := optimized.RAM(address), plain.RAM(address); got != want {
+				t.Errorf("%q: RAM[%d] = %d, want %d", source, address, got, want)
+			}
+		}
+	}
+}
+
+// BenchmarkTranslate reports the instruction count of each project 07 and 08
+// test program for every translation mode; run it with go test -bench .
+func BenchmarkTranslate(b *testing.B) {
+	var dirs []string
+	for _, pattern := range []string{"../../../../07/*/*", "../../../*/*"} {
+		matches, err := filepath.Glob(filepath.Join(pattern, "*.vm"))
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, match := range matches {
+			if dir := filepath.Dir(match); len(dirs) == 0 || dirs[len(dirs)-1] != dir {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	if len(dirs) == 0 {
+		b.Skip("no test programs found")
+	}
+
+	for _, dir := range dirs {
+		for _, c := range bench.Configs {
+			b.Run(filepath.Base(dir)+"/"+c.Name, func(b *testing.B) {
+				count := 0
+				for i := 0; i < b.N; i++ {
+					var err error
+					if count, err = bench.CountInstructions(dir, c); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(count), "instructions")
+			})
+		}
+	}
+}
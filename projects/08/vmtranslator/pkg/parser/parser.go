@@ -2,8 +2,12 @@ package parser
 
 import (
 	"bufio"
-	"log"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -21,99 +25,249 @@ const (
 	CmdCall
 )
 
-type Parser struct {
-	instructions         []string
-	currInstructionIndex int
+const MaxConstant = 32767
+
+type Command struct {
+	Type CmdType
+	Arg1 string
+	Arg2 int
+	File string
+	Line int
+	Text string
+}
+
+func (c Command) Pos() string {
+	return filepath.Base(c.File) + ":" + strconv.Itoa(c.Line)
+}
+
+type Error struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e Error) Error() string {
+	return filepath.Base(e.File) + ":" + strconv.Itoa(e.Line) + ": " + e.Msg
 }
 
-func New(filePath string) *Parser {
-	return &Parser{
-		instructions:         readInstructions(filePath),
-		currInstructionIndex: -1,
+type ErrorList []Error
+
+func (l ErrorList) Error() string {
+	var messages []string
+	for _, err := range l {
+		messages = append(messages, err.Error())
 	}
+	return strings.Join(messages, "\n")
+}
+
+var commandTypes = map[string]CmdType{
+	"add":      CmdArithmetic,
+	"sub":      CmdArithmetic,
+	"neg":      CmdArithmetic,
+	"eq":       CmdArithmetic,
+	"gt":       CmdArithmetic,
+	"lt":       CmdArithmetic,
+	"and":      CmdArithmetic,
+	"or":       CmdArithmetic,
+	"not":      CmdArithmetic,
+	"push":     CmdPush,
+	"pop":      CmdPop,
+	"label":    CmdLabel,
+	"goto":     CmdGoto,
+	"if-goto":  CmdIf,
+	"function": CmdFunction,
+	"call":     CmdCall,
+	"return":   CmdReturn,
+}
+
+var argCounts = map[CmdType]int{
+	CmdArithmetic: 0,
+	CmdPush:       2,
+	CmdPop:        2,
+	CmdLabel:      1,
+	CmdGoto:       1,
+	CmdIf:         1,
+	CmdFunction:   2,
+	CmdReturn:     0,
+	CmdCall:       2,
+}
+
+var segments = map[string]bool{
+	"argument": true,
+	"local":    true,
+	"static":   true,
+	"constant": true,
+	"this":     true,
+	"that":     true,
+	"pointer":  true,
+	"temp":     true,
 }
 
-func readInstructions(filePath string) []string {
+func ListFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		if filepath.Ext(path) != ".vm" {
+			return nil, fmt.Errorf("%s: not a .vm file", path)
+		}
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".vm" {
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s: no .vm files found", path)
+	}
+	return files, nil
+}
+
+func ParseFile(filePath string) ([]Command, error) {
 	f, err := os.Open(filePath)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer f.Close()
+	return Parse(f, filePath)
+}
 
-	var instructions []string
-	scanner := bufio.NewScanner(f)
+func Parse(r io.Reader, filePath string) ([]Command, error) {
+	var commands []Command
+	var errs ErrorList
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
 	for scanner.Scan() {
+		lineNumber++
 		line, _, _ := strings.Cut(scanner.Text(), "//")
-		if line = strings.TrimSpace(line); line != "" {
-			instructions = append(instructions, line)
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
 		}
+
+		cmd, err := parseCommand(fields)
+		if err != nil {
+			errs = append(errs, Error{File: filePath, Line: lineNumber, Msg: err.Error()})
+			continue
+		}
+		cmd.File = filePath
+		cmd.Line = lineNumber
+		cmd.Text = strings.Join(fields, " ")
+		commands = append(commands, cmd)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
 	}
-	return instructions
-}
 
-func (p *Parser) getInstructionParts() []string {
-	return strings.Split(p.instructions[p.currInstructionIndex], " ")
+	errs = append(errs, checkLabels(commands)...)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return commands, nil
 }
 
-func (p *Parser) HasMoreLines() bool {
-	return p.currInstructionIndex < len(p.instructions)-1
-}
+func parseCommand(fields []string) (Command, error) {
+	cmdType, found := commandTypes[fields[0]]
+	if !found {
+		return Command{}, fmt.Errorf("unknown command %q", fields[0])
+	}
+	if len(fields)-1 != argCounts[cmdType] {
+		return Command{}, fmt.Errorf("%s expects %d arguments, found %d", fields[0], argCounts[cmdType], len(fields)-1)
+	}
 
-func (p *Parser) Advance() {
-	if p.HasMoreLines() {
-		p.currInstructionIndex++
+	cmd := Command{Type: cmdType, Arg1: fields[0], Arg2: 0, File: "", Line: 0, Text: ""}
+	if len(fields) > 1 {
+		cmd.Arg1 = fields[1]
+	}
+	if len(fields) > 2 {
+		index, err := strconv.Atoi(fields[2])
+		if err != nil || index < 0 {
+			return Command{}, fmt.Errorf("invalid index %q, expected a non-negative integer", fields[2])
+		}
+		cmd.Arg2 = index
 	}
-}
 
-func (p *Parser) CommandType() CmdType {
-	switch p.getInstructionParts()[0] {
-	case "add":
-		return CmdArithmetic
-	case "sub":
-		return CmdArithmetic
-	case "neg":
-		return CmdArithmetic
-	case "eq":
-		return CmdArithmetic
-	case "gt":
-		return CmdArithmetic
-	case "lt":
-		return CmdArithmetic
-	case "and":
-		return CmdArithmetic
-	case "or":
-		return CmdArithmetic
-	case "not":
-		return CmdArithmetic
-	case "push":
-		return CmdPush
-	case "pop":
-		return CmdPop
-	case "label":
-		return CmdLabel
-	case "goto":
-		return CmdGoto
-	case "if-goto":
-		return CmdIf
-	case "function":
-		return CmdFunction
-	case "call":
-		return CmdCall
-	case "return":
-		return CmdReturn
+	switch cmdType {
+	case CmdPush, CmdPop:
+		return cmd, checkSegment(fields[0], cmd.Arg1, cmd.Arg2)
+	case CmdLabel, CmdGoto, CmdIf, CmdFunction, CmdCall:
+		if !isValidSymbol(cmd.Arg1) {
+			return Command{}, fmt.Errorf("invalid name %q", cmd.Arg1)
+		}
 	}
+	return cmd, nil
+}
 
-	return CmdLabel
+func checkSegment(command, segment string, index int) error {
+	if !segments[segment] {
+		return fmt.Errorf("unknown segment %q", segment)
+	}
+	switch {
+	case command == "pop" && segment == "constant":
+		return fmt.Errorf("cannot pop to constant segment")
+	case segment == "constant" && index > MaxConstant:
+		return fmt.Errorf("constant %d exceeds %d", index, MaxConstant)
+	case segment == "pointer" && index > 1:
+		return fmt.Errorf("pointer index %d out of range 0-1", index)
+	case segment == "temp" && index > 7:
+		return fmt.Errorf("temp index %d out of range 0-7", index)
+	}
+	return nil
 }
 
-func (p *Parser) Arg1() string {
-	if p.CommandType() == CmdArithmetic {
-		return p.getInstructionParts()[0]
+func checkLabels(commands []Command) []Error {
+	var errs []Error
+	start := 0
+	for i := 0; i <= len(commands); i++ {
+		if i == len(commands) || commands[i].Type == CmdFunction {
+			errs = append(errs, checkFunctionLabels(commands[start:i])...)
+			start = i
+		}
 	}
+	return errs
+}
 
-	return p.getInstructionParts()[1]
+func checkFunctionLabels(commands []Command) []Error {
+	var errs []Error
+	labels := map[string]Command{}
+	for _, cmd := range commands {
+		if cmd.Type != CmdLabel {
+			continue
+		}
+		if other, found := labels[cmd.Arg1]; found {
+			errs = append(errs, Error{File: cmd.File, Line: cmd.Line, Msg: fmt.Sprintf("label %s already defined at %s", cmd.Arg1, other.Pos())})
+			continue
+		}
+		labels[cmd.Arg1] = cmd
+	}
+	for _, cmd := range commands {
+		if cmd.Type != CmdGoto && cmd.Type != CmdIf {
+			continue
+		}
+		if _, found := labels[cmd.Arg1]; !found {
+			errs = append(errs, Error{File: cmd.File, Line: cmd.Line, Msg: fmt.Sprintf("undefined label %s", cmd.Arg1)})
+		}
+	}
+	return errs
 }
 
-func (p *Parser) Arg2() string {
-	return p.getInstructionParts()[2]
+func isValidSymbol(symbol string) bool {
+	if symbol == "" || symbol[0] >= '0' && symbol[0] <= '9' {
+		return false
+	}
+	for _, c := range symbol {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("_.:$", c)) {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,319 @@
+package peephole
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Stats struct {
+	Before int
+	After  int
+}
+
+type instruction struct {
+	text     string
+	dest     string
+	comp     string
+	jump     string
+	comments []string
+}
+
+func parse(line string) instruction {
+	code, comment, hasComment := strings.Cut(line, "//")
+	code = strings.Join(strings.Fields(code), "")
+	inst := instruction{text: code, dest: "", comp: "", jump: "", comments: nil}
+	if hasComment {
+		inst.comments = []string{"//" + comment}
+	}
+	if !isCInstruction(code) {
+		return inst
+	}
+
+	rest := code
+	if dest, comp, found := strings.Cut(rest, "="); found {
+		inst.dest = dest
+		rest = comp
+	}
+	inst.comp, inst.jump, _ = strings.Cut(rest, ";")
+	return inst
+}
+
+func isCInstruction(code string) bool {
+	return code != "" && !strings.HasPrefix(code, "@") && !strings.HasPrefix(code, "(") && !strings.HasPrefix(code, ".")
+}
+
+func (i instruction) isA() bool {
+	return strings.HasPrefix(i.text, "@")
+}
+
+func (i instruction) isC() bool {
+	return isCInstruction(i.text)
+}
+
+func (i instruction) isInstruction() bool {
+	return i.isA() || i.isC()
+}
+
+func (i instruction) writes(register string) bool {
+	return strings.Contains(i.dest, register)
+}
+
+func Count(lines []string) int {
+	count := 0
+	for _, line := range lines {
+		if parse(line).isInstruction() {
+			count++
+		}
+	}
+	return count
+}
+
+// Comments are kept in front of the instruction they preceded, or the next
+// surviving one if that instruction is removed.
+type emitter struct {
+	output  []instruction
+	pending []string
+	changed bool
+}
+
+func (e *emitter) keep(inst instruction) {
+	if len(e.pending) > 0 {
+		inst.comments = append(e.pending, inst.comments...)
+		e.pending = nil
+	}
+	e.output = append(e.output, inst)
+}
+
+func (e *emitter) drop(instructions ...instruction) {
+	for _, inst := range instructions {
+		e.pending = append(e.pending, inst.comments...)
+	}
+	e.changed = true
+}
+
+func (e *emitter) result() ([]instruction, bool) {
+	if len(e.pending) > 0 {
+		e.output = append(e.output, instruction{text: "", dest: "", comp: "", jump: "", comments: e.pending})
+	}
+	return e.output, e.changed
+}
+
+func Optimize(lines []string) ([]string, Stats, error) {
+	instructions := parseLines(lines)
+	if err := checkRelocatable(instructions); err != nil {
+		return nil, Stats{Before: 0, After: 0}, err
+	}
+
+	passes := []func([]instruction) ([]instruction, bool){
+		removeDeadCode,
+		removeJumpsToNext,
+		removePushPop,
+		removeRedundantLoads,
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, pass := range passes {
+			var passChanged bool
+			instructions, passChanged = pass(instructions)
+			changed = changed || passChanged
+		}
+	}
+
+	optimized := formatLines(instructions)
+	return optimized, Stats{Before: Count(lines), After: Count(optimized)}, nil
+}
+
+func parseLines(lines []string) []instruction {
+	e := &emitter{output: nil, pending: nil, changed: false}
+	for _, line := range lines {
+		inst := parse(line)
+		if inst.text == "" {
+			e.pending = append(e.pending, inst.comments...)
+			continue
+		}
+		e.keep(inst)
+	}
+	instructions, _ := e.result()
+	return instructions
+}
+
+func formatLines(instructions []instruction) []string {
+	var lines []string
+	for _, inst := range instructions {
+		lines = append(lines, inst.comments...)
+		if inst.text != "" {
+			lines = append(lines, inst.text)
+		}
+	}
+	return lines
+}
+
+// Removing instructions moves code, so jumps to hard-coded ROM addresses
+// would land in the wrong place.
+// register describes what A or D holds between two labels: a value
+// computed only from the numeric A-instruction number, or the contents of
+// the symbol loadedFrom.
+type register struct {
+	number     string
+	loadedFrom string
+}
+
+// checkRelocatable refuses programs that jump to numeric ROM addresses,
+// which would point elsewhere once instructions are removed. A number
+// reaches a jump either directly through A or D, or by being stored in a
+// symbol that a later jump loads its target from. Addresses passed through
+// the stack or a pointer are not tracked.
+func checkRelocatable(instructions []instruction) error {
+	storedNumbers := map[string]string{}
+	jumpSources := map[string]bool{}
+	var a, d register
+	symbol := ""
+	for _, inst := range instructions {
+		switch {
+		case inst.isA():
+			a = register{number: "", loadedFrom: ""}
+			symbol = inst.text[1:]
+			if _, err := strconv.Atoi(symbol); err == nil {
+				a.number = inst.text
+				symbol = ""
+			}
+		case inst.isC():
+			if inst.jump != "" && a.number != "" {
+				return fmt.Errorf("jump to absolute ROM address %s, cannot optimize", a.number)
+			}
+			if inst.jump != "" && a.loadedFrom != "" {
+				jumpSources[a.loadedFrom] = true
+			}
+
+			value := register{number: "", loadedFrom: ""}
+			usesA, usesD := strings.Contains(inst.comp, "A"), strings.Contains(inst.comp, "D")
+			switch {
+			case inst.comp == "M":
+				value.loadedFrom = symbol
+			case strings.Contains(inst.comp, "M"):
+			case usesA && usesD:
+				if a.number != "" && d.number != "" {
+					value.number = a.number
+				}
+			case usesA:
+				value.number = a.number
+			case usesD:
+				value.number = d.number
+			}
+			if inst.writes("M") && value.number != "" && symbol != "" {
+				storedNumbers[symbol] = value.number
+			}
+			if inst.writes("D") {
+				d = value
+			}
+			if inst.writes("A") {
+				a = value
+				symbol = ""
+			}
+		default:
+			a = register{number: "", loadedFrom: ""}
+			d = register{number: "", loadedFrom: ""}
+			symbol = ""
+		}
+	}
+
+	var symbols []string
+	for symbol := range storedNumbers {
+		if jumpSources[symbol] {
+			symbols = append(symbols, symbol)
+		}
+	}
+	if len(symbols) > 0 {
+		sort.Strings(symbols)
+		return fmt.Errorf("ROM address %s stored in %s is used as a jump target, cannot optimize", storedNumbers[symbols[0]], symbols[0])
+	}
+	return nil
+}
+
+// Everything between an unconditional jump and the next label is unreachable.
+func removeDeadCode(instructions []instruction) ([]instruction, bool) {
+	e := &emitter{output: nil, pending: nil, changed: false}
+	unreachable := false
+	for _, inst := range instructions {
+		if !inst.isInstruction() {
+			unreachable = false
+		}
+		if unreachable {
+			e.drop(inst)
+			continue
+		}
+		e.keep(inst)
+		if inst.jump == "JMP" {
+			unreachable = true
+		}
+	}
+	return e.result()
+}
+
+// @L / 0;JMP / (L) jumps to the instruction that would run anyway.
+func removeJumpsToNext(instructions []instruction) ([]instruction, bool) {
+	e := &emitter{output: nil, pending: nil, changed: false}
+	for i := 0; i < len(instructions); i++ {
+		if i+2 < len(instructions) && instructions[i].isA() && instructions[i+1].jump != "" && instructions[i+1].dest == "" &&
+			instructions[i+2].text == "("+instructions[i].text[1:]+")" {
+			e.drop(instructions[i : i+2]...)
+			i++
+			continue
+		}
+		e.keep(instructions[i])
+	}
+	return e.result()
+}
+
+// @X / M=M+1 / @X / M=M-1 leaves memory and D untouched, only A=X remains.
+func removePushPop(instructions []instruction) ([]instruction, bool) {
+	e := &emitter{output: nil, pending: nil, changed: false}
+	for i := 0; i < len(instructions); i++ {
+		if i+3 < len(instructions) && instructions[i].isA() && instructions[i+1].text == "M=M+1" &&
+			instructions[i+2].text == instructions[i].text && instructions[i+3].text == "M=M-1" {
+			e.keep(instructions[i])
+			e.drop(instructions[i+1 : i+4]...)
+			i += 3
+			continue
+		}
+		e.keep(instructions[i])
+	}
+	return e.result()
+}
+
+// Drops A loads that are overwritten before use, A loads of the value A
+// already holds, and D=M right after M=D (or the reverse) at the same address.
+func removeRedundantLoads(instructions []instruction) ([]instruction, bool) {
+	e := &emitter{output: nil, pending: nil, changed: false}
+	knownA := ""
+	dEqualsM := false
+	for i, inst := range instructions {
+		switch {
+		case inst.isA():
+			if inst.text == knownA || i+1 < len(instructions) && instructions[i+1].isA() {
+				e.drop(inst)
+				continue
+			}
+			knownA = inst.text
+			dEqualsM = false
+		case inst.isC():
+			if dEqualsM && inst.jump == "" && (inst.text == "D=M" || inst.text == "M=D") {
+				e.drop(inst)
+				continue
+			}
+			if inst.writes("A") {
+				knownA = ""
+				dEqualsM = false
+			} else if inst.writes("D") || inst.writes("M") {
+				dEqualsM = inst.writes("D") && inst.writes("M") || inst.text == "D=M" || inst.text == "M=D"
+			}
+		default:
+			knownA = ""
+			dEqualsM = false
+		}
+		e.keep(inst)
+	}
+	return e.result()
+}
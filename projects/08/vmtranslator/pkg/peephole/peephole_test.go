@@ -0,0 +1,222 @@
+package peephole
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"assembler/pkg/assembler"
+	"assembler/pkg/hackcpu"
+)
+
+type passTest struct {
+	name string
+	in   []string
+	want []string
+}
+
+func runPassTests(t *testing.T, pass func([]instruction) ([]instruction, bool), tests []passTest) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, changed := pass(parseLines(tt.in))
+			got := formatLines(out)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if wantChanged := !reflect.DeepEqual(tt.in, tt.want); changed != wantChanged {
+				t.Errorf("changed = %v, want %v", changed, wantChanged)
+			}
+		})
+	}
+}
+
+func TestRemoveDeadCode(t *testing.T) {
+	runPassTests(t, removeDeadCode, []passTest{
+		{
+			name: "code after unconditional jump",
+			in:   []string{"@END", "0;JMP", "D=M", "@5", "(L)", "D=A"},
+			want: []string{"@END", "0;JMP", "(L)", "D=A"},
+		},
+		{
+			name: "conditional jump",
+			in:   []string{"@L", "D;JGT", "D=M", "(L)"},
+			want: []string{"@L", "D;JGT", "D=M", "(L)"},
+		},
+		{
+			name: "comments of dead code move to the next label",
+			in:   []string{"@END", "0;JMP", "// dead", "D=M", "(L)"},
+			want: []string{"@END", "0;JMP", "// dead", "(L)"},
+		},
+		{
+			name: "dead code at the end",
+			in:   []string{"(END)", "@END", "0;JMP", "M=0"},
+			want: []string{"(END)", "@END", "0;JMP"},
+		},
+	})
+}
+
+func TestRemoveJumpsToNext(t *testing.T) {
+	runPassTests(t, removeJumpsToNext, []passTest{
+		{
+			name: "unconditional jump to next",
+			in:   []string{"@L", "0;JMP", "(L)", "D=M"},
+			want: []string{"(L)", "D=M"},
+		},
+		{
+			name: "conditional jump to next",
+			in:   []string{"@L", "D;JEQ", "(L)"},
+			want: []string{"(L)"},
+		},
+		{
+			name: "jump with a dest",
+			in:   []string{"@L", "D=M;JMP", "(L)"},
+			want: []string{"@L", "D=M;JMP", "(L)"},
+		},
+		{
+			name: "jump to another label",
+			in:   []string{"@L", "0;JMP", "(M)"},
+			want: []string{"@L", "0;JMP", "(M)"},
+		},
+	})
+}
+
+func TestRemovePushPop(t *testing.T) {
+	runPassTests(t, removePushPop, []passTest{
+		{
+			name: "increment then decrement",
+			in:   []string{"@SP", "M=M+1", "@SP", "M=M-1", "D=M"},
+			want: []string{"@SP", "D=M"},
+		},
+		{
+			name: "different addresses",
+			in:   []string{"@SP", "M=M+1", "@R13", "M=M-1"},
+			want: []string{"@SP", "M=M+1", "@R13", "M=M-1"},
+		},
+		{
+			name: "comments are kept",
+			in:   []string{"// push", "@SP", "M=M+1", "// pop", "@SP", "M=M-1", "D=M"},
+			want: []string{"// push", "@SP", "// pop", "D=M"},
+		},
+	})
+}
+
+func TestRemoveRedundantLoads(t *testing.T) {
+	runPassTests(t, removeRedundantLoads, []passTest{
+		{
+			name: "overwritten load",
+			in:   []string{"@5", "@SP", "M=0"},
+			want: []string{"@SP", "M=0"},
+		},
+		{
+			name: "reload of the same address",
+			in:   []string{"@SP", "M=M+1", "@SP", "A=M-1"},
+			want: []string{"@SP", "M=M+1", "A=M-1"},
+		},
+		{
+			name: "reload after A is written",
+			in:   []string{"@SP", "A=M", "@SP", "M=0"},
+			want: []string{"@SP", "A=M", "@SP", "M=0"},
+		},
+		{
+			name: "reload after a label",
+			in:   []string{"@SP", "M=0", "(L)", "@SP", "M=1"},
+			want: []string{"@SP", "M=0", "(L)", "@SP", "M=1"},
+		},
+		{
+			name: "M=D after D=M",
+			in:   []string{"@R13", "D=M", "M=D"},
+			want: []string{"@R13", "D=M"},
+		},
+		{
+			name: "D=M after M=D",
+			in:   []string{"@R13", "M=D", "D=M"},
+			want: []string{"@R13", "M=D"},
+		},
+		{
+			name: "D changed in between",
+			in:   []string{"@R13", "D=M", "D=D+1", "M=D"},
+			want: []string{"@R13", "D=M", "D=D+1", "M=D"},
+		},
+	})
+}
+
+func TestOptimizeRelocatable(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      []string
+		wantErr bool
+	}{
+		{name: "unconditional jump to a number", in: []string{"@5", "0;JMP"}, wantErr: true},
+		{name: "conditional jump to a number", in: []string{"@100", "D;JGT"}, wantErr: true},
+		{name: "computed jump target", in: []string{"@R14", "A=M", "0;JMP"}, wantErr: false},
+		{name: "jump to a label", in: []string{"@LOOP", "0;JMP", "(LOOP)"}, wantErr: false},
+		{name: "number used as a constant", in: []string{"@5", "D=A", "@SP", "M=D"}, wantErr: false},
+		{name: "number moved through D", in: []string{"@7", "D=A", "A=D", "0;JMP"}, wantErr: true},
+		{name: "number stored as a return address", in: []string{"@123", "D=A", "@R15", "M=D", "@R15", "A=M", "0;JMP"}, wantErr: true},
+		{name: "number stored in a variable", in: []string{"@5", "D=A", "@R13", "M=D", "@R14", "A=M", "0;JMP"}, wantErr: false},
+		{name: "label stored as a return address", in: []string{"@RET", "D=A", "@R15", "M=D", "@R15", "A=M", "0;JMP", "(RET)"}, wantErr: false},
+		{name: "number offset from memory", in: []string{"@LCL", "D=M", "@5", "A=D-A", "D=M", "@R14", "M=D", "@R14", "A=M", "0;JMP"}, wantErr: false},
+		{name: "number passed through the stack is not tracked", in: []string{"@123", "D=A", "@SP", "A=M", "M=D", "@SP", "A=M", "A=M", "0;JMP"}, wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Optimize(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOptimizeStats(t *testing.T) {
+	in := []string{"// push", "@SP", "M=M+1", "@SP", "M=M-1", "(END)", "@END", "0;JMP", "D=M"}
+	_, stats, err := Optimize(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats != (Stats{Before: 7, After: 3}) {
+		t.Errorf("stats = %+v, want {Before:7 After:3}", stats)
+	}
+}
+
+// sumProgram adds 1..10 into R0 and contains something for every pass to
+// remove.
+var sumProgram = []string{
+	"@i", "M=1", "@R0", "M=0",
+	"(LOOP)",
+	"@i", "D=M", "@11", "D=D-A", "@END", "D;JEQ",
+	"@i", "D=M", "@R0", "M=D+M",
+	"@SP", "M=M+1", "@SP", "M=M-1",
+	"@i", "M=M+1",
+	"@LOOP", "0;JMP",
+	"@i", "M=0",
+	"(END)",
+	"@END", "0;JMP",
+}
+
+func TestOptimizePreservesBehaviour(t *testing.T) {
+	optimized, stats, err := Optimize(sumProgram)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.After >= stats.Before {
+		t.Errorf("nothing removed: %+v", stats)
+	}
+
+	for _, lines := range [][]string{sumProgram, optimized} {
+		program, err := assembler.Assemble(strings.NewReader(strings.Join(lines, "\n")), assembler.Options{Filename: "sum.asm"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cpu := hackcpu.New()
+		cpu.LoadProgram(program.Words())
+		cpu.SetRAM(0, 0)
+		if err := cpu.Run(1000); err != nil {
+			t.Fatal(err)
+		}
+		if got := cpu.RAM(0); got != 55 {
+			t.Errorf("RAM[0] = %d, want 55\n%s", got, strings.Join(lines, "\n"))
+		}
+	}
+}
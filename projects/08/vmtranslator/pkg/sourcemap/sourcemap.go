@@ -0,0 +1,118 @@
+package sourcemap
+
+import (
+	"assembler/pkg/assembler"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type Entry struct {
+	Start    int
+	End      int
+	File     string
+	Line     int
+	Function string
+	Command  string
+}
+
+type location struct {
+	file     string
+	line     int
+	function string
+	command  string
+}
+
+// Build maps ROM addresses back to the VM commands whose
+// "// File.vm:line: command" comments precede them in asm. Consecutive
+// comments, such as those of a fused push/pop pair, all share the
+// instructions that follow them.
+func Build(asm []string, program *assembler.Program) []Entry {
+	groups := make([]int, len(asm)+1)
+	var locations [][]location
+	current := location{file: "", line: 0, function: "", command: ""}
+	inComments := false
+	for i, line := range asm {
+		line = strings.TrimSpace(line)
+		isComment := strings.HasPrefix(line, "// ")
+		if isComment {
+			current = parseComment(strings.TrimPrefix(line, "// "), current)
+			if inComments {
+				locations[len(locations)-1] = append(locations[len(locations)-1], current)
+			} else {
+				locations = append(locations, []location{current})
+			}
+		}
+		if line != "" {
+			inComments = isComment
+		}
+		groups[i+1] = len(locations)
+	}
+
+	var entries []Entry
+	lastGroup, lastAddress, groupSize := -1, -1, 0
+	for _, instruction := range program.Instructions {
+		group := 0
+		if instruction.Pos.Line < len(groups) {
+			group = groups[instruction.Pos.Line]
+		}
+
+		if group == lastGroup && instruction.Address == lastAddress+1 {
+			for i := len(entries) - groupSize; i < len(entries); i++ {
+				entries[i].End = instruction.Address
+			}
+			lastAddress = instruction.Address
+			continue
+		}
+
+		groupLocations := []location{{file: "", line: 0, function: "", command: ""}}
+		if group > 0 {
+			groupLocations = locations[group-1]
+		}
+		for _, loc := range groupLocations {
+			entries = append(entries, Entry{
+				Start:    instruction.Address,
+				End:      instruction.Address,
+				File:     loc.file,
+				Line:     loc.line,
+				Function: loc.function,
+				Command:  loc.command,
+			})
+		}
+		lastGroup, lastAddress, groupSize = group, instruction.Address, len(groupLocations)
+	}
+	return entries
+}
+
+func parseComment(comment string, previous location) location {
+	pos, command, found := strings.Cut(comment, ": ")
+	file, lineStr, hasLine := strings.Cut(pos, ":")
+	line, err := strconv.Atoi(lineStr)
+	if !found || !hasLine || err != nil {
+		return location{file: "", line: 0, function: "", command: comment}
+	}
+
+	function := previous.function
+	if file != previous.file {
+		function = ""
+	}
+	if fields := strings.Fields(command); len(fields) > 1 && fields[0] == "function" {
+		function = fields[1]
+	}
+	return location{file: file, line: line, function: function, command: command}
+}
+
+func Write(w io.StringWriter, entries []Entry) {
+	w.WriteString("# start\tend\tfile\tline\tfunction\tcommand\n")
+	for _, entry := range entries {
+		file, function := entry.File, entry.Function
+		if file == "" {
+			file = "-"
+		}
+		if function == "" {
+			function = "-"
+		}
+		w.WriteString(fmt.Sprintf("%d\t%d\t%s\t%d\t%s\t%s\n", entry.Start, entry.End, file, entry.Line, function, entry.Command))
+	}
+}
@@ -0,0 +1,164 @@
+package translator
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"vmtranslator/pkg/codewriter"
+	"vmtranslator/pkg/parser"
+)
+
+type BootstrapMode int
+
+const (
+	BootstrapAuto BootstrapMode = iota
+	BootstrapOn
+	BootstrapOff
+)
+
+type VMFile struct {
+	Name   string
+	Source io.Reader
+}
+
+// A zero StackBase means codewriter.DefaultStackBase.
+type Options struct {
+	Bootstrap BootstrapMode
+	StackBase int
+	Compact   bool
+	Optimize  bool
+	Comments  bool
+}
+
+func LoadFiles(path string) ([]VMFile, error) {
+	paths, err := parser.ListFiles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []VMFile
+	for _, filePath := range paths {
+		source, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, VMFile{Name: filePath, Source: bytes.NewReader(source)})
+	}
+	return files, nil
+}
+
+func Translate(files []VMFile, opts Options) ([]string, error) {
+	parsed, err := parseFiles(files)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.StackBase < 0 || opts.StackBase > parser.MaxConstant {
+		return nil, fmt.Errorf("invalid stack base %d", opts.StackBase)
+	}
+
+	cw := codewriter.New()
+	cw.SetCompact(opts.Compact)
+	cw.SetOptimize(opts.Optimize)
+	if opts.StackBase != 0 {
+		cw.SetStackBase(opts.StackBase)
+	}
+	if opts.Bootstrap == BootstrapOn || opts.Bootstrap == BootstrapAuto && hasFunction(parsed, "Sys.init") {
+		if opts.Comments {
+			cw.WriteComment("bootstrap")
+		}
+		cw.WriteBootstrap()
+	}
+
+	for i, file := range files {
+		cw.SetFileName(strings.Split(filepath.Base(file.Name), ".")[0])
+		translateCommands(cw, parsed[i], opts)
+	}
+	if opts.Comments {
+		cw.WriteComment("end")
+	}
+	cw.WriteEnd()
+	return cw.Instructions(), nil
+}
+
+func parseFiles(files []VMFile) ([][]parser.Command, error) {
+	var parsed [][]parser.Command
+	var errs parser.ErrorList
+	functions := map[string]parser.Command{}
+	for _, file := range files {
+		commands, err := parser.Parse(file.Source, file.Name)
+		if list, ok := err.(parser.ErrorList); ok {
+			errs = append(errs, list...)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, cmd := range commands {
+			if cmd.Type != parser.CmdFunction {
+				continue
+			}
+			if other, found := functions[cmd.Arg1]; found {
+				errs = append(errs, parser.Error{File: cmd.File, Line: cmd.Line, Msg: fmt.Sprintf("function %s already defined at %s", cmd.Arg1, other.Pos())})
+				continue
+			}
+			functions[cmd.Arg1] = cmd
+		}
+		parsed = append(parsed, commands)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return parsed, nil
+}
+
+func hasFunction(parsed [][]parser.Command, name string) bool {
+	for _, commands := range parsed {
+		for _, cmd := range commands {
+			if cmd.Type == parser.CmdFunction && cmd.Arg1 == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func translateCommands(cw *codewriter.CodeWriter, commands []parser.Command, opts Options) {
+	for i := 0; i < len(commands); i++ {
+		cmd := commands[i]
+		if opts.Optimize && i+1 < len(commands) && codewriter.CanFuse(cmd, commands[i+1]) {
+			if opts.Comments {
+				cw.WriteComment(cmd.Pos() + ": " + cmd.Text)
+				cw.WriteComment(commands[i+1].Pos() + ": " + commands[i+1].Text)
+			}
+			cw.WriteFused(cmd, commands[i+1])
+			i++
+			continue
+		}
+
+		if opts.Comments {
+			cw.WriteComment(cmd.Pos() + ": " + cmd.Text)
+		}
+		switch cmd.Type {
+		case parser.CmdArithmetic:
+			cw.WriteArithmetic(cmd.Arg1)
+		case parser.CmdPush, parser.CmdPop:
+			cw.WritePushPop(cmd.Type, cmd.Arg1, cmd.Arg2)
+		case parser.CmdLabel:
+			cw.WriteLabel(cmd.Arg1)
+		case parser.CmdIf:
+			cw.WriteIf(cmd.Arg1)
+		case parser.CmdGoto:
+			cw.WriteGoto(cmd.Arg1)
+		case parser.CmdFunction:
+			cw.WriteFunction(cmd.Arg1, cmd.Arg2)
+		case parser.CmdCall:
+			cw.WriteCall(cmd.Arg1, cmd.Arg2)
+		case parser.CmdReturn:
+			cw.WriteReturn()
+		}
+	}
+}
@@ -0,0 +1,454 @@
+package vmemu
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"vmtranslator/pkg/parser"
+)
+
+const (
+	MemorySize = 32768
+	SP         = 0
+	LCL        = 1
+	ARG        = 2
+	THIS       = 3
+	THAT       = 4
+	TempBase   = 5
+	StaticBase = 16
+	StackBase  = 256
+	// Return addresses are pushed onto the stack as int16 command indexes.
+	MaxCommands = 32767
+)
+
+type instruction struct {
+	cmdType  parser.CmdType
+	command  string
+	arg1     string
+	arg2     int
+	file     string
+	pos      string
+	function string
+}
+
+type Frame struct {
+	Function      string
+	ReturnAddress int
+}
+
+type Emulator struct {
+	instructions []instruction
+	functions    map[string]int
+	labels       map[string]int
+	statics      map[string]int
+	ram          [MemorySize]int16
+	pc           int
+	callStack    []Frame
+	steps        int
+}
+
+func New() *Emulator {
+	return &Emulator{
+		instructions: nil,
+		functions:    map[string]int{},
+		labels:       map[string]int{},
+		statics:      map[string]int{},
+		pc:           0,
+		callStack:    nil,
+		steps:        0,
+	}
+}
+
+func (e *Emulator) LoadFile(filePath string) error {
+	commands, err := parser.ParseFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	fileName := strings.Split(filepath.Base(filePath), ".")[0]
+	currFunction := ""
+	for _, cmd := range commands {
+		inst := instruction{
+			cmdType:  cmd.Type,
+			command:  cmd.Text,
+			arg1:     cmd.Arg1,
+			arg2:     cmd.Arg2,
+			file:     fileName,
+			pos:      cmd.Pos(),
+			function: currFunction,
+		}
+
+		index := len(e.instructions)
+		if index == MaxCommands {
+			return fmt.Errorf("%s: program exceeds %d VM commands", inst.pos, MaxCommands)
+		}
+		switch inst.cmdType {
+		case parser.CmdFunction:
+			if other, found := e.functions[inst.arg1]; found {
+				return fmt.Errorf("%s: function %s already defined at %s", inst.pos, inst.arg1, e.instructions[other].pos)
+			}
+			currFunction = inst.arg1
+			inst.function = currFunction
+			e.functions[inst.arg1] = index
+		case parser.CmdLabel:
+			e.labels[scopedLabel(inst, inst.arg1)] = index
+		case parser.CmdPush, parser.CmdPop:
+			if inst.arg1 != "static" {
+				break
+			}
+			if err := e.allocateStatic(fileName, inst.arg2); err != nil {
+				return fmt.Errorf("%s: %v", inst.pos, err)
+			}
+		}
+		e.instructions = append(e.instructions, inst)
+	}
+
+	e.Reset()
+	return nil
+}
+
+// Statics live in RAM[16..255] like the assembler's variables, so at most
+// StackBase-StaticBase of them fit across all files.
+func (e *Emulator) allocateStatic(fileName string, index int) error {
+	name := fileName + "." + strconv.Itoa(index)
+	if _, found := e.statics[name]; found {
+		return nil
+	}
+	if StaticBase+len(e.statics) >= StackBase {
+		return fmt.Errorf("static segment overflow: more than %d static variables", StackBase-StaticBase)
+	}
+	e.statics[name] = StaticBase + len(e.statics)
+	return nil
+}
+
+// Labels outside a function are scoped to their file, as in codewriter.
+func scopedLabel(inst instruction, label string) string {
+	if inst.function == "" {
+		return inst.file + "$" + label
+	}
+	return inst.function + "$" + label
+}
+
+func (e *Emulator) Reset() {
+	e.pc = 0
+	if index, found := e.functions["Sys.init"]; found {
+		e.pc = index
+	}
+	e.callStack = nil
+	e.steps = 0
+	e.skipLabels()
+}
+
+func (e *Emulator) Bootstrap() error {
+	e.ram[SP] = StackBase
+	e.callStack = nil
+	e.pc = len(e.instructions)
+	if err := e.call("Sys.init", 0); err != nil {
+		return err
+	}
+	e.skipLabels()
+	return nil
+}
+
+func (e *Emulator) IsHalted() bool {
+	return e.pc >= len(e.instructions)
+}
+
+func (e *Emulator) Run(steps int) error {
+	for i := 0; i < steps && !e.IsHalted(); i++ {
+		if err := e.Step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Emulator) Step() error {
+	if e.IsHalted() {
+		return fmt.Errorf("program halted")
+	}
+	inst := e.instructions[e.pc]
+	e.pc++
+	e.steps++
+	if err := e.execute(inst); err != nil {
+		return fmt.Errorf("%s: %s: %w", inst.pos, inst.command, err)
+	}
+	e.skipLabels()
+	return nil
+}
+
+func (e *Emulator) skipLabels() {
+	for !e.IsHalted() && e.instructions[e.pc].cmdType == parser.CmdLabel {
+		e.pc++
+	}
+}
+
+func (e *Emulator) execute(inst instruction) error {
+	switch inst.cmdType {
+	case parser.CmdArithmetic:
+		return e.arithmetic(inst.arg1)
+	case parser.CmdPush:
+		addr, err := e.segmentAddress(inst.arg1, inst.arg2, inst.file)
+		if err == errConstant {
+			return e.push(int16(inst.arg2))
+		}
+		if err != nil {
+			return err
+		}
+		return e.push(e.ram[addr])
+	case parser.CmdPop:
+		addr, err := e.segmentAddress(inst.arg1, inst.arg2, inst.file)
+		if err == errConstant {
+			return fmt.Errorf("cannot pop to constant segment")
+		}
+		if err != nil {
+			return err
+		}
+		value, err := e.pop()
+		if err != nil {
+			return err
+		}
+		e.ram[addr] = value
+	case parser.CmdLabel:
+	case parser.CmdGoto:
+		return e.jump(inst)
+	case parser.CmdIf:
+		value, err := e.pop()
+		if err != nil {
+			return err
+		}
+		if value != 0 {
+			return e.jump(inst)
+		}
+	case parser.CmdFunction:
+		for i := 0; i < inst.arg2; i++ {
+			if err := e.push(0); err != nil {
+				return err
+			}
+		}
+	case parser.CmdCall:
+		return e.call(inst.arg1, inst.arg2)
+	case parser.CmdReturn:
+		return e.ret()
+	}
+	return nil
+}
+
+func (e *Emulator) jump(inst instruction) error {
+	index, found := e.labels[scopedLabel(inst, inst.arg1)]
+	if !found {
+		return fmt.Errorf("unknown label %s", inst.arg1)
+	}
+	e.pc = index
+	return nil
+}
+
+func (e *Emulator) arithmetic(command string) error {
+	y, err := e.pop()
+	if err != nil {
+		return err
+	}
+	switch command {
+	case "neg":
+		return e.push(-y)
+	case "not":
+		return e.push(^y)
+	}
+
+	x, err := e.pop()
+	if err != nil {
+		return err
+	}
+	switch command {
+	case "add":
+		return e.push(x + y)
+	case "sub":
+		return e.push(x - y)
+	case "and":
+		return e.push(x & y)
+	case "or":
+		return e.push(x | y)
+	case "eq":
+		return e.push(boolValue(x == y))
+	case "gt":
+		return e.push(boolValue(x > y))
+	case "lt":
+		return e.push(boolValue(x < y))
+	}
+	return fmt.Errorf("unknown command %s", command)
+}
+
+func boolValue(b bool) int16 {
+	if b {
+		return -1
+	}
+	return 0
+}
+
+var errConstant = errors.New("constant segment")
+
+func (e *Emulator) segmentAddress(segment string, index int, fileName string) (int, error) {
+	var addr int
+	switch segment {
+	case "constant":
+		return 0, errConstant
+	case "local":
+		addr = int(e.ram[LCL]) + index
+	case "argument":
+		addr = int(e.ram[ARG]) + index
+	case "this":
+		addr = int(e.ram[THIS]) + index
+	case "that":
+		addr = int(e.ram[THAT]) + index
+	case "pointer":
+		if index > 1 {
+			return 0, fmt.Errorf("pointer index %d out of range", index)
+		}
+		addr = THIS + index
+	case "temp":
+		if index > 7 {
+			return 0, fmt.Errorf("temp index %d out of range", index)
+		}
+		addr = TempBase + index
+	case "static":
+		addr = e.statics[fileName+"."+strconv.Itoa(index)]
+	default:
+		return 0, fmt.Errorf("unknown segment %s", segment)
+	}
+	if addr < 0 || addr >= MemorySize {
+		return 0, fmt.Errorf("address %d out of range", addr)
+	}
+	return addr, nil
+}
+
+func (e *Emulator) push(value int16) error {
+	sp := int(e.ram[SP])
+	if sp < 0 || sp >= MemorySize {
+		return fmt.Errorf("stack overflow")
+	}
+	e.ram[sp] = value
+	e.ram[SP]++
+	return nil
+}
+
+func (e *Emulator) pop() (int16, error) {
+	sp := int(e.ram[SP]) - 1
+	if sp < 0 || sp >= MemorySize {
+		return 0, fmt.Errorf("stack underflow")
+	}
+	e.ram[SP]--
+	return e.ram[sp], nil
+}
+
+func (e *Emulator) call(function string, nArgs int) error {
+	index, found := e.functions[function]
+	if !found {
+		return fmt.Errorf("unknown function %s", function)
+	}
+
+	for _, value := range []int16{int16(e.pc), e.ram[LCL], e.ram[ARG], e.ram[THIS], e.ram[THAT]} {
+		if err := e.push(value); err != nil {
+			return err
+		}
+	}
+	e.ram[ARG] = e.ram[SP] - 5 - int16(nArgs)
+	e.ram[LCL] = e.ram[SP]
+	e.callStack = append(e.callStack, Frame{Function: function, ReturnAddress: e.pc})
+	e.pc = index
+	return nil
+}
+
+func (e *Emulator) ret() error {
+	frame := int(e.ram[LCL])
+	if frame < 5 || frame >= MemorySize {
+		return fmt.Errorf("invalid frame pointer %d", frame)
+	}
+	returnAddress := int(e.ram[frame-5])
+	if returnAddress < 0 || returnAddress > len(e.instructions) {
+		return fmt.Errorf("invalid return address %d", returnAddress)
+	}
+	value, err := e.pop()
+	if err != nil {
+		return err
+	}
+	arg := int(e.ram[ARG])
+	if arg < 0 || arg >= MemorySize {
+		return fmt.Errorf("invalid argument pointer %d", arg)
+	}
+	e.ram[arg] = value
+	e.ram[SP] = int16(arg + 1)
+	e.ram[THAT] = e.ram[frame-1]
+	e.ram[THIS] = e.ram[frame-2]
+	e.ram[ARG] = e.ram[frame-3]
+	e.ram[LCL] = e.ram[frame-4]
+	if len(e.callStack) > 0 {
+		e.callStack = e.callStack[:len(e.callStack)-1]
+	}
+	e.pc = returnAddress
+	return nil
+}
+
+func (e *Emulator) PC() int {
+	return e.pc
+}
+
+func (e *Emulator) Steps() int {
+	return e.steps
+}
+
+func (e *Emulator) CurrentCommand() string {
+	if e.IsHalted() {
+		return ""
+	}
+	return e.instructions[e.pc].command
+}
+
+func (e *Emulator) CurrentFunction() string {
+	if e.IsHalted() {
+		return ""
+	}
+	return e.instructions[e.pc].function
+}
+
+func (e *Emulator) RAM(addr int) int16 {
+	return e.ram[addr]
+}
+
+func (e *Emulator) SetRAM(addr int, value int16) {
+	e.ram[addr] = value
+}
+
+func (e *Emulator) Stack() []int16 {
+	sp := int(e.ram[SP])
+	if sp < StackBase || sp > MemorySize {
+		return nil
+	}
+	return e.ram[StackBase:sp]
+}
+
+func (e *Emulator) Segment(segment string, index int) (int16, error) {
+	if segment == "static" {
+		if e.IsHalted() {
+			return 0, fmt.Errorf("no current file")
+		}
+		addr, found := e.statics[e.instructions[e.pc].file+"."+strconv.Itoa(index)]
+		if !found {
+			return 0, fmt.Errorf("static %d not used in %s", index, e.instructions[e.pc].file)
+		}
+		return e.ram[addr], nil
+	}
+	addr, err := e.segmentAddress(segment, index, "")
+	if err == errConstant {
+		return int16(index), nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return e.ram[addr], nil
+}
+
+func (e *Emulator) CallStack() []Frame {
+	return e.callStack
+}
@@ -0,0 +1,63 @@
+package vmemu
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeVM(t *testing.T, source string) string {
+	t.Helper()
+	filePath := filepath.Join(t.TempDir(), "Main.vm")
+	if err := os.WriteFile(filePath, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filePath
+}
+
+func TestLoadFileTooManyCommands(t *testing.T) {
+	source := "function Main.main 0\n" + strings.Repeat("push constant 1\npop temp 0\n", MaxCommands/2) + "return\n"
+	err := New().LoadFile(writeVM(t, source))
+	if err == nil || !strings.HasSuffix(err.Error(), "Main.vm:32768: program exceeds 32767 VM commands") {
+		t.Fatalf("err = %v", err)
+	}
+
+	source = "function Main.main 0\n" + strings.Repeat("push constant 1\npop temp 0\n", MaxCommands/2-1) + "return\n"
+	if err := New().LoadFile(writeVM(t, source)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCallReturnsToHighCommandIndex(t *testing.T) {
+	source := "function Sys.init 0\n" + strings.Repeat("push constant 1\npop temp 0\n", 16379) +
+		"call Main.one 0\npop temp 1\nlabel END\ngoto END\n" +
+		"function Main.one 0\npush constant 1\nreturn\n"
+	e := New()
+	if err := e.LoadFile(writeVM(t, source)); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Run(40010); err != nil {
+		t.Fatal(err)
+	}
+	if got := e.RAM(TempBase + 1); got != 1 {
+		t.Errorf("temp 1 = %d, want 1", got)
+	}
+}
+
+func TestReturnToInvalidAddress(t *testing.T) {
+	e := New()
+	if err := e.LoadFile(writeVM(t, "function Main.main 0\npush constant 0\nreturn\n")); err != nil {
+		t.Fatal(err)
+	}
+	e.SetRAM(SP, 300)
+	e.SetRAM(LCL, 300)
+	e.SetRAM(ARG, 295)
+	e.SetRAM(295, -1)
+	if err := e.Run(10); err == nil || !strings.Contains(err.Error(), "invalid return address -1") {
+		t.Fatalf("err = %v", err)
+	}
+}
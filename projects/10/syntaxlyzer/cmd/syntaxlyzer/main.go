@@ -1,6 +1,11 @@
 package main
 
 import (
+	"compiler/pkg/ast"
+	"compiler/pkg/diag"
+	"compiler/pkg/parser"
+	"compiler/pkg/tokenizer"
+	"fmt"
 	"io/fs"
 	"log"
 	"os"
@@ -8,14 +13,16 @@ import (
 	"path/filepath"
 	"strings"
 	"syntaxlyzer/pkg/compiler"
-	"syntaxlyzer/pkg/tokenizer"
 )
 
 func main() {
 	inputPath := path.Clean(os.Args[1])
 
 	var filePaths []string
-	inputPathStats, _ := os.Stat(inputPath)
+	inputPathStats, err := os.Stat(inputPath)
+	if err != nil {
+		log.Fatal(err)
+	}
 	if inputPathStats.IsDir() {
 		filepath.WalkDir(inputPath, func(filePath string, d fs.DirEntry, err error) error {
 			if path.Ext(filePath) == ".jack" {
@@ -28,20 +35,65 @@ func main() {
 	}
 
 	os.Mkdir("out", os.ModePerm)
+	hasFailedClasses := false
 	for _, filePath := range filePaths {
+		println("compiling", filePath)
+		reporter := diag.NewReporter()
+		class := parseFile(filePath, reporter)
+		if reporter.HasErrors() {
+			for _, d := range reporter.Diagnostics() {
+				fmt.Fprintln(os.Stderr, d)
+			}
+			hasFailedClasses = true
+			continue
+		}
+
 		filename := path.Base(filePath)
-		xmlOutputFilename := filename[:strings.LastIndex(filename, ".")] + ".xml"
-		outputFile, err := os.Create(path.Join("out", xmlOutputFilename))
+		className := filename[:strings.LastIndex(filename, ".")]
+		writeTokensFile(filePath, path.Join("out", className+"T.xml"))
+
+		outputFile, err := os.Create(path.Join("out", className+".xml"))
 		if err != nil {
 			log.Fatal(err)
 		}
 
-		println("compiling", filePath)
-		t := tokenizer.New(filePath)
-		c := compiler.New(t, outputFile)
-		c.CompileClass()
+		c := compiler.New(outputFile)
+		c.CompileClass(class)
 
 		outputFile.Sync()
 		outputFile.Close()
 	}
+
+	if hasFailedClasses {
+		os.Exit(1)
+	}
+}
+
+func parseFile(filePath string, reporter *diag.Reporter) *ast.Class {
+	file, err := os.Open(filePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	p := parser.New(tokenizer.New(file, filePath, reporter), reporter)
+	return p.ParseClass()
+}
+
+func writeTokensFile(filePath, outputFilePath string) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	outputFile, err := os.Create(outputFilePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer outputFile.Close()
+
+	c := compiler.New(outputFile)
+	c.CompileTokens(tokenizer.New(file, filePath, diag.NewReporter()))
+	outputFile.Sync()
 }
@@ -1,3 +1,7 @@
 module syntaxlyzer
 
 go 1.18
+
+require compiler v0.0.0
+
+replace compiler => ../../11/compiler
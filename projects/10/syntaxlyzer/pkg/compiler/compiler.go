@@ -1,66 +1,20 @@
 package compiler
 
 import (
-	"log"
-	"os"
+	"compiler/pkg/ast"
+	"compiler/pkg/tokenizer"
+	"io"
 	"strconv"
 	"strings"
-	"syntaxlyzer/pkg/tokenizer"
 )
 
 type Compiler struct {
-	tokenizer  *tokenizer.Tokenizer
-	outputFile *os.File
+	output io.StringWriter
 }
 
-func New(tokenizer *tokenizer.Tokenizer, outputFile *os.File) *Compiler {
+func New(output io.StringWriter) *Compiler {
 	return &Compiler{
-		tokenizer:  tokenizer,
-		outputFile: outputFile,
-	}
-}
-
-func (c *Compiler) getCurrentToken() string {
-	switch c.tokenizer.TokenType() {
-	case tokenizer.Keyword:
-		return c.tokenizer.KeyWord()
-	case tokenizer.Symbol:
-		return c.tokenizer.Symbol()
-	case tokenizer.Identifier:
-		return c.tokenizer.Identifier()
-	case tokenizer.IntConst:
-		return strconv.Itoa(c.tokenizer.IntVal())
-	case tokenizer.StringConst:
-		return c.tokenizer.StringVal()
-	}
-	return ""
-}
-
-func (c *Compiler) process(str string) {
-	if str == c.getCurrentToken() {
-		c.writeXMLTokenOutput(str)
-	} else {
-		log.Println("syntax error:", str)
-	}
-	c.tokenizer.Advance()
-}
-
-func (c *Compiler) processCurrentToken() {
-	c.process(c.getCurrentToken())
-}
-
-func (c *Compiler) writeXMLTokenOutput(token string) {
-	switch c.tokenizer.TokenType() {
-	case tokenizer.Keyword:
-		c.outputFile.WriteString(createXMLToken("keyword", token))
-	case tokenizer.Symbol:
-		c.outputFile.WriteString(createXMLToken("symbol", token))
-	case tokenizer.Identifier:
-		c.outputFile.WriteString(createXMLToken("identifier", token))
-	case tokenizer.IntConst:
-		c.outputFile.WriteString(createXMLToken("integerConstant", token))
-	case tokenizer.StringConst:
-		c.outputFile.WriteString(createXMLToken("stringConstant", token))
+		output: output,
 	}
 }
 
@@ -83,259 +37,269 @@ func createXMLToken(tokenName, value string) string {
 	return sb.String()
 }
 
-func (c *Compiler) CompileClass() {
-	c.outputFile.WriteString("<class>\n")
-	c.process("class")
-	c.processCurrentToken()
-	c.process("{")
-	for c.getCurrentToken() == "static" || c.getCurrentToken() == "field" {
-		c.CompileClassVarDec()
-	}
-	for c.getCurrentToken() == "constructor" || c.getCurrentToken() == "function" || c.getCurrentToken() == "method" {
-		c.CompileSubroutine()
+func (c *Compiler) CompileTokens(t *tokenizer.Tokenizer) {
+	c.output.WriteString("<tokens>\n")
+	for ; !t.AtEnd(); t.Advance() {
+		token := t.Token()
+		switch token.Type {
+		case tokenizer.Keyword:
+			c.output.WriteString(createXMLToken("keyword", token.Text))
+		case tokenizer.Symbol:
+			c.output.WriteString(createXMLToken("symbol", token.Text))
+		case tokenizer.Identifier:
+			c.output.WriteString(createXMLToken("identifier", token.Text))
+		case tokenizer.IntConst:
+			c.output.WriteString(createXMLToken("integerConstant", token.Text))
+		case tokenizer.StringConst:
+			c.output.WriteString(createXMLToken("stringConstant", token.Text))
+		}
 	}
-	c.process("}")
-	c.outputFile.WriteString("</class>")
+	c.output.WriteString("</tokens>\n")
 }
 
-func (c *Compiler) CompileClassVarDec() {
-	c.outputFile.WriteString("<classVarDec>\n")
-	if c.getCurrentToken() == "static" {
-		c.process("static")
-	} else {
-		c.process("field")
-	}
-	c.processCurrentToken()
-	c.processCurrentToken()
-	if c.getCurrentToken() == "," {
-		c.process(",")
-		c.processCurrentToken()
+func (c *Compiler) writeKeyword(keyword string) {
+	c.output.WriteString(createXMLToken("keyword", keyword))
+}
+
+func (c *Compiler) writeSymbol(symbol string) {
+	c.output.WriteString(createXMLToken("symbol", symbol))
+}
+
+func (c *Compiler) writeIdentifier(identifier ast.Ident) {
+	c.output.WriteString(createXMLToken("identifier", identifier.Name))
+}
+
+func (c *Compiler) writeType(typeIdent ast.Ident) {
+	switch typeIdent.Name {
+	case "int", "char", "boolean", "void":
+		c.writeKeyword(typeIdent.Name)
+	default:
+		c.writeIdentifier(typeIdent)
 	}
-	c.process(";")
-	c.outputFile.WriteString("</classVarDec>\n")
 }
 
-func (c *Compiler) CompileSubroutine() {
-	c.outputFile.WriteString("<subroutineDec>\n")
-	if c.getCurrentToken() == "constructor" {
-		c.process("constructor")
-	} else if c.getCurrentToken() == "function" {
-		c.process("function")
-	} else {
-		c.process("method")
+func (c *Compiler) CompileClass(class *ast.Class) {
+	c.output.WriteString("<class>\n")
+	c.writeKeyword("class")
+	c.writeIdentifier(class.Name)
+	c.writeSymbol("{")
+	for _, varDec := range class.VarDecs {
+		c.CompileClassVarDec(varDec)
 	}
-	if c.getCurrentToken() == "void" {
-		c.process("void")
-	} else {
-		c.processCurrentToken()
+	for _, subroutine := range class.Subroutines {
+		c.CompileSubroutine(subroutine)
 	}
-	c.processCurrentToken()
-	c.process("(")
-	c.CompileParameterList()
-	c.process(")")
-	c.CompileSubroutineBody()
-	c.outputFile.WriteString("</subroutineDec>\n")
+	c.writeSymbol("}")
+	c.output.WriteString("</class>")
 }
 
-func (c *Compiler) CompileParameterList() {
-	c.outputFile.WriteString("<parameterList>\n")
-	token := c.getCurrentToken()
-	isBuiltInType := token == "int" || token == "char" || token == "boolean"
-	if isBuiltInType || c.tokenizer.TokenType() == tokenizer.Identifier {
-		c.processCurrentToken()
-		c.processCurrentToken()
-	}
-	for c.getCurrentToken() == "," {
-		c.process(",")
-		c.processCurrentToken()
-		c.processCurrentToken()
+func (c *Compiler) CompileClassVarDec(varDec *ast.ClassVarDec) {
+	c.output.WriteString("<classVarDec>\n")
+	c.writeKeyword(varDec.Kind)
+	c.writeType(varDec.Type)
+	c.compileNameList(varDec.Names)
+	c.writeSymbol(";")
+	c.output.WriteString("</classVarDec>\n")
+}
+
+func (c *Compiler) compileNameList(names []ast.Ident) {
+	for i, name := range names {
+		if i > 0 {
+			c.writeSymbol(",")
+		}
+		c.writeIdentifier(name)
 	}
-	c.outputFile.WriteString("</parameterList>\n")
 }
 
-func (c *Compiler) CompileSubroutineBody() {
-	c.outputFile.WriteString("<subroutineBody>\n")
-	c.process("{")
-	for c.getCurrentToken() == "var" {
-		c.CompileVarDec()
+func (c *Compiler) CompileSubroutine(subroutine *ast.SubroutineDec) {
+	c.output.WriteString("<subroutineDec>\n")
+	c.writeKeyword(subroutine.Kind)
+	c.writeType(subroutine.ReturnType)
+	c.writeIdentifier(subroutine.Name)
+	c.writeSymbol("(")
+	c.CompileParameterList(subroutine.Params)
+	c.writeSymbol(")")
+	c.CompileSubroutineBody(subroutine)
+	c.output.WriteString("</subroutineDec>\n")
+}
+
+func (c *Compiler) CompileParameterList(params []*ast.Param) {
+	c.output.WriteString("<parameterList>\n")
+	for i, param := range params {
+		if i > 0 {
+			c.writeSymbol(",")
+		}
+		c.writeType(param.Type)
+		c.writeIdentifier(param.Name)
 	}
-	c.CompileStatements()
-	c.process("}")
-	c.outputFile.WriteString("</subroutineBody>\n")
+	c.output.WriteString("</parameterList>\n")
 }
 
-func (c *Compiler) CompileVarDec() {
-	c.outputFile.WriteString("<varDec>\n")
-	c.process("var")
-	c.processCurrentToken()
-	c.processCurrentToken()
-	if c.getCurrentToken() == "," {
-		c.process(",")
-		c.processCurrentToken()
+func (c *Compiler) CompileSubroutineBody(subroutine *ast.SubroutineDec) {
+	c.output.WriteString("<subroutineBody>\n")
+	c.writeSymbol("{")
+	for _, varDec := range subroutine.VarDecs {
+		c.CompileVarDec(varDec)
 	}
-	c.process(";")
-	c.outputFile.WriteString("</varDec>\n")
+	c.CompileStatements(subroutine.Statements)
+	c.writeSymbol("}")
+	c.output.WriteString("</subroutineBody>\n")
 }
 
-func (c *Compiler) CompileStatements() {
-	c.outputFile.WriteString("<statements>\n")
-	stop := false
-	for !stop {
-		switch c.getCurrentToken() {
-		case "let":
-			c.CompileLet()
-		case "if":
-			c.CompileIf()
-		case "while":
-			c.CompileWhile()
-		case "do":
-			c.compileDo()
-		case "return":
-			c.CompileReturn()
-		default:
-			stop = true
+func (c *Compiler) CompileVarDec(varDec *ast.VarDec) {
+	c.output.WriteString("<varDec>\n")
+	c.writeKeyword("var")
+	c.writeType(varDec.Type)
+	c.compileNameList(varDec.Names)
+	c.writeSymbol(";")
+	c.output.WriteString("</varDec>\n")
+}
+
+func (c *Compiler) CompileStatements(statements []ast.Statement) {
+	c.output.WriteString("<statements>\n")
+	for _, statement := range statements {
+		switch s := statement.(type) {
+		case *ast.LetStatement:
+			c.CompileLet(s)
+		case *ast.IfStatement:
+			c.CompileIf(s)
+		case *ast.WhileStatement:
+			c.CompileWhile(s)
+		case *ast.DoStatement:
+			c.compileDo(s)
+		case *ast.ReturnStatement:
+			c.CompileReturn(s)
 		}
 	}
-	c.outputFile.WriteString("</statements>\n")
+	c.output.WriteString("</statements>\n")
 }
 
-func (c *Compiler) CompileLet() {
-	c.outputFile.WriteString("<letStatement>\n")
-	c.process("let")
-	c.processCurrentToken()
-	if c.getCurrentToken() == "[" {
-		c.process("[")
-		c.CompileExpression()
-		c.process("]")
+func (c *Compiler) CompileLet(statement *ast.LetStatement) {
+	c.output.WriteString("<letStatement>\n")
+	c.writeKeyword("let")
+	c.writeIdentifier(statement.Name)
+	if statement.Index != nil {
+		c.writeSymbol("[")
+		c.CompileExpression(statement.Index)
+		c.writeSymbol("]")
 	}
-	c.process("=")
-	c.CompileExpression()
-	c.process(";")
-	c.outputFile.WriteString("</letStatement>\n")
+	c.writeSymbol("=")
+	c.CompileExpression(statement.Value)
+	c.writeSymbol(";")
+	c.output.WriteString("</letStatement>\n")
 }
 
-func (c *Compiler) CompileIf() {
-	c.outputFile.WriteString("<ifStatement>\n")
-	c.process("if")
-	c.process("(")
-	c.CompileExpression()
-	c.process(")")
-	c.process("{")
-	c.CompileStatements()
-	c.process("}")
-	if c.getCurrentToken() == "else" {
-		c.process("else")
-		c.process("{")
-		c.CompileStatements()
-		c.process("}")
+func (c *Compiler) CompileIf(statement *ast.IfStatement) {
+	c.output.WriteString("<ifStatement>\n")
+	c.writeKeyword("if")
+	c.writeSymbol("(")
+	c.CompileExpression(statement.Condition)
+	c.writeSymbol(")")
+	c.writeSymbol("{")
+	c.CompileStatements(statement.Then)
+	c.writeSymbol("}")
+	if statement.HasElse {
+		c.writeKeyword("else")
+		c.writeSymbol("{")
+		c.CompileStatements(statement.Else)
+		c.writeSymbol("}")
 	}
-	c.outputFile.WriteString("</ifStatement>\n")
+	c.output.WriteString("</ifStatement>\n")
 }
 
-func (c *Compiler) CompileWhile() {
-	c.outputFile.WriteString("<whileStatement>\n")
-	c.process("while")
-	c.process("(")
-	c.CompileExpression()
-	c.process(")")
-	c.process("{")
-	c.CompileStatements()
-	c.process("}")
-	c.outputFile.WriteString("</whileStatement>\n")
+func (c *Compiler) CompileWhile(statement *ast.WhileStatement) {
+	c.output.WriteString("<whileStatement>\n")
+	c.writeKeyword("while")
+	c.writeSymbol("(")
+	c.CompileExpression(statement.Condition)
+	c.writeSymbol(")")
+	c.writeSymbol("{")
+	c.CompileStatements(statement.Body)
+	c.writeSymbol("}")
+	c.output.WriteString("</whileStatement>\n")
 }
 
-func (c *Compiler) compileDo() {
-	c.outputFile.WriteString("<doStatement>\n")
-	c.process("do")
-	c.processCurrentToken()
-	if c.getCurrentToken() == "(" {
-		c.process("(")
-		c.CompileExpressionList()
-		c.process(")")
-	} else {
-		c.process(".")
-		c.processCurrentToken()
-		c.process("(")
-		c.CompileExpressionList()
-		c.process(")")
-	}
-	c.process(";")
-	c.outputFile.WriteString("</doStatement>\n")
+func (c *Compiler) compileDo(statement *ast.DoStatement) {
+	c.output.WriteString("<doStatement>\n")
+	c.writeKeyword("do")
+	c.compileSubroutineCall(statement.Call)
+	c.writeSymbol(";")
+	c.output.WriteString("</doStatement>\n")
 }
 
-func (c *Compiler) CompileReturn() {
-	c.outputFile.WriteString("<returnStatement>\n")
-	c.process("return")
-	if c.getCurrentToken() != ";" {
-		c.CompileExpression()
+func (c *Compiler) compileSubroutineCall(call *ast.SubroutineCall) {
+	if call.Receiver.Name != "" {
+		c.writeIdentifier(call.Receiver)
+		c.writeSymbol(".")
 	}
-	c.process(";")
-	c.outputFile.WriteString("</returnStatement>\n")
+	c.writeIdentifier(call.Name)
+	c.writeSymbol("(")
+	c.CompileExpressionList(call.Args)
+	c.writeSymbol(")")
 }
 
-func (c *Compiler) CompileExpression() {
-	c.outputFile.WriteString("<expression>\n")
-	c.CompileTerm()
-	for isOp(c.getCurrentToken()) {
-		c.processCurrentToken()
-		c.CompileTerm()
+func (c *Compiler) CompileReturn(statement *ast.ReturnStatement) {
+	c.output.WriteString("<returnStatement>\n")
+	c.writeKeyword("return")
+	if statement.Value != nil {
+		c.CompileExpression(statement.Value)
 	}
-	c.outputFile.WriteString("</expression>\n")
+	c.writeSymbol(";")
+	c.output.WriteString("</returnStatement>\n")
 }
 
-func isOp(token string) bool {
-	operators := []string{"+", "-", "*", "/", "&", "|", "<", ">", "="}
-	for _, op := range operators {
-		if token == op {
-			return true
-		}
-	}
-	return false
+func (c *Compiler) CompileExpression(expression ast.Expression) {
+	c.output.WriteString("<expression>\n")
+	c.compileOperation(expression)
+	c.output.WriteString("</expression>\n")
 }
 
-func (c *Compiler) CompileTerm() {
-	c.outputFile.WriteString("<term>\n")
-	if c.getCurrentToken() == "(" {
-		c.process("(")
-		c.CompileExpression()
-		c.process(")")
-	} else if c.getCurrentToken() == "-" || c.getCurrentToken() == "~" {
-		c.processCurrentToken()
-		c.CompileTerm()
-	} else {
-		c.processCurrentToken()
-		if c.getCurrentToken() == "[" {
-			c.process("[")
-			c.CompileExpression()
-			c.process("]")
-		} else if c.getCurrentToken() == "(" {
-			c.process("(")
-			c.CompileExpressionList()
-			c.process(")")
-		} else if c.getCurrentToken() == "." {
-			c.process(".")
-			c.processCurrentToken()
-			c.process("(")
-			c.CompileExpressionList()
-			c.process(")")
-		}
+func (c *Compiler) compileOperation(expression ast.Expression) {
+	if e, ok := expression.(*ast.BinaryExpression); ok {
+		c.compileOperation(e.Left)
+		c.writeSymbol(e.Operator)
+		c.CompileTerm(e.Right)
+		return
 	}
-	c.outputFile.WriteString("</term>\n")
+	c.CompileTerm(expression)
 }
 
-func (c *Compiler) CompileExpressionList() int {
-	c.outputFile.WriteString("<expressionList>\n")
-	if c.getCurrentToken() == ")" {
-		c.outputFile.WriteString("</expressionList>\n")
-		return 0
+func (c *Compiler) CompileTerm(expression ast.Expression) {
+	c.output.WriteString("<term>\n")
+	switch e := expression.(type) {
+	case *ast.ParenExpression:
+		c.writeSymbol("(")
+		c.CompileExpression(e.Inner)
+		c.writeSymbol(")")
+	case *ast.UnaryExpression:
+		c.writeSymbol(e.Operator)
+		c.CompileTerm(e.Operand)
+	case *ast.IntegerConstant:
+		c.output.WriteString(createXMLToken("integerConstant", strconv.Itoa(e.Value)))
+	case *ast.StringConstant:
+		c.output.WriteString(createXMLToken("stringConstant", e.Value))
+	case *ast.KeywordConstant:
+		c.writeKeyword(e.Value)
+	case *ast.VarRef:
+		c.writeIdentifier(e.Name)
+	case *ast.ArrayAccess:
+		c.writeIdentifier(e.Name)
+		c.writeSymbol("[")
+		c.CompileExpression(e.Index)
+		c.writeSymbol("]")
+	case *ast.SubroutineCall:
+		c.compileSubroutineCall(e)
 	}
-	c.CompileExpression()
-	i := 1
-	for c.getCurrentToken() == "," {
-		c.process(",")
-		c.CompileExpression()
-		i++
+	c.output.WriteString("</term>\n")
+}
+
+func (c *Compiler) CompileExpressionList(expressions []ast.Expression) {
+	c.output.WriteString("<expressionList>\n")
+	for i, expression := range expressions {
+		if i > 0 {
+			c.writeSymbol(",")
+		}
+		c.CompileExpression(expression)
 	}
-	c.outputFile.WriteString("</expressionList>\n")
-	return i
+	c.output.WriteString("</expressionList>\n")
 }
@@ -1,10 +1,16 @@
 package main
 
 import (
+	"compiler/pkg/ast"
 	"compiler/pkg/compengine"
+	"compiler/pkg/diag"
+	"compiler/pkg/parser"
+	"compiler/pkg/semantic"
 	"compiler/pkg/symtable"
 	"compiler/pkg/tokenizer"
 	"compiler/pkg/vmwriter"
+	"flag"
+	"fmt"
 	"io/fs"
 	"log"
 	"os"
@@ -14,10 +20,15 @@ import (
 )
 
 func main() {
-	inputPath := path.Clean(os.Args[1])
+	isStrict := flag.Bool("strict", false, "type-check assignments, returns, operators, conditions and calls")
+	flag.Parse()
+	inputPath := path.Clean(flag.Arg(0))
 
 	var filePaths []string
-	inputPathStats, _ := os.Stat(inputPath)
+	inputPathStats, err := os.Stat(inputPath)
+	if err != nil {
+		log.Fatal(err)
+	}
 	if inputPathStats.IsDir() {
 		filepath.WalkDir(inputPath, func(filePath string, d fs.DirEntry, err error) error {
 			if path.Ext(filePath) == ".jack" {
@@ -31,23 +42,69 @@ func main() {
 
 	os.RemoveAll("out")
 	os.Mkdir("out", os.ModePerm)
+	hasFailedClasses := false
+	program := semantic.NewProgram()
+	classes := make(map[string]*ast.Class)
+	reporters := make(map[string]*diag.Reporter)
+	hasSyntaxErrors := make(map[string]bool)
 	for _, filePath := range filePaths {
-		filename := path.Base(filePath)
-		xmlOutputFilename := filename[:strings.LastIndex(filename, ".")] + ".vm"
-		outputFile, err := os.Create(path.Join("out", xmlOutputFilename))
-		if err != nil {
-			log.Fatal(err)
+		reporter := diag.NewReporter()
+		class := parseFile(filePath, reporter)
+		hasSyntaxErrors[filePath] = reporter.HasErrors()
+		if class.Name.Name != "" {
+			program.AddClass(semantic.DeclareClass(class, reporter), reporter)
 		}
+		classes[filePath] = class
+		reporters[filePath] = reporter
+	}
 
+	for _, filePath := range filePaths {
 		println("compiling", filePath)
-		t := tokenizer.New(filePath)
-		w := vmwriter.New(outputFile)
+		reporter := reporters[filePath]
+		if !hasSyntaxErrors[filePath] && classes[filePath].Name.Name != "" {
+			checker := semantic.NewChecker(program, reporter)
+			checker.SetStrict(*isStrict)
+			checker.CheckClass(classes[filePath])
+		}
+
+		if reporter.HasErrors() {
+			for _, d := range reporter.Diagnostics() {
+				fmt.Fprintln(os.Stderr, d)
+			}
+			hasFailedClasses = true
+			continue
+		}
+
+		var output strings.Builder
+		w := vmwriter.New(&output)
 		classSymTable := symtable.New()
 		subroutineSymTable := symtable.New()
-		c := compengine.New(t, w, classSymTable, subroutineSymTable, outputFile)
-		c.CompileClass()
+		c := compengine.New(w, classSymTable, subroutineSymTable)
+		c.CompileClass(classes[filePath])
 
+		filename := path.Base(filePath)
+		vmOutputFilename := filename[:strings.LastIndex(filename, ".")] + ".vm"
+		outputFile, err := os.Create(path.Join("out", vmOutputFilename))
+		if err != nil {
+			log.Fatal(err)
+		}
+		outputFile.WriteString(output.String())
 		outputFile.Sync()
 		outputFile.Close()
 	}
+
+	if hasFailedClasses {
+		os.Exit(1)
+	}
+}
+
+func parseFile(filePath string, reporter *diag.Reporter) *ast.Class {
+	file, err := os.Open(filePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	p := parser.New(tokenizer.New(file, filePath, reporter), reporter)
+	return p.ParseClass()
 }
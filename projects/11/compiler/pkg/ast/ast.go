@@ -0,0 +1,184 @@
+package ast
+
+import (
+	"compiler/pkg/diag"
+)
+
+type Node interface {
+	Pos() diag.Pos
+}
+
+type Statement interface {
+	Node
+	statementNode()
+}
+
+type Expression interface {
+	Node
+	expressionNode()
+}
+
+type Ident struct {
+	Name     string
+	Position diag.Pos
+}
+
+func (i Ident) Pos() diag.Pos { return i.Position }
+
+type Class struct {
+	Name        Ident
+	VarDecs     []*ClassVarDec
+	Subroutines []*SubroutineDec
+	Position    diag.Pos
+}
+
+type ClassVarDec struct {
+	Kind     string
+	Type     Ident
+	Names    []Ident
+	Position diag.Pos
+}
+
+type SubroutineDec struct {
+	Kind       string
+	ReturnType Ident
+	Name       Ident
+	Params     []*Param
+	VarDecs    []*VarDec
+	Statements []Statement
+	Position   diag.Pos
+}
+
+type Param struct {
+	Type Ident
+	Name Ident
+}
+
+type VarDec struct {
+	Type     Ident
+	Names    []Ident
+	Position diag.Pos
+}
+
+func (c *Class) Pos() diag.Pos         { return c.Position }
+func (d *ClassVarDec) Pos() diag.Pos   { return d.Position }
+func (d *SubroutineDec) Pos() diag.Pos { return d.Position }
+func (p *Param) Pos() diag.Pos         { return p.Type.Position }
+func (d *VarDec) Pos() diag.Pos        { return d.Position }
+
+type LetStatement struct {
+	Name     Ident
+	Index    Expression
+	Value    Expression
+	Position diag.Pos
+}
+
+type IfStatement struct {
+	Condition Expression
+	Then      []Statement
+	HasElse   bool
+	Else      []Statement
+	Position  diag.Pos
+}
+
+type WhileStatement struct {
+	Condition Expression
+	Body      []Statement
+	Position  diag.Pos
+}
+
+type DoStatement struct {
+	Call     *SubroutineCall
+	Position diag.Pos
+}
+
+type ReturnStatement struct {
+	Value    Expression
+	Position diag.Pos
+}
+
+func (s *LetStatement) Pos() diag.Pos    { return s.Position }
+func (s *IfStatement) Pos() diag.Pos     { return s.Position }
+func (s *WhileStatement) Pos() diag.Pos  { return s.Position }
+func (s *DoStatement) Pos() diag.Pos     { return s.Position }
+func (s *ReturnStatement) Pos() diag.Pos { return s.Position }
+
+func (*LetStatement) statementNode()    {}
+func (*IfStatement) statementNode()     {}
+func (*WhileStatement) statementNode()  {}
+func (*DoStatement) statementNode()     {}
+func (*ReturnStatement) statementNode() {}
+
+type BinaryExpression struct {
+	Operator    string
+	OperatorPos diag.Pos
+	Left        Expression
+	Right       Expression
+}
+
+type UnaryExpression struct {
+	Operator string
+	Operand  Expression
+	Position diag.Pos
+}
+
+type ParenExpression struct {
+	Inner    Expression
+	Position diag.Pos
+}
+
+type IntegerConstant struct {
+	Value    int
+	Position diag.Pos
+}
+
+type StringConstant struct {
+	Value    string
+	Position diag.Pos
+}
+
+type KeywordConstant struct {
+	Value    string
+	Position diag.Pos
+}
+
+type VarRef struct {
+	Name Ident
+}
+
+type ArrayAccess struct {
+	Name  Ident
+	Index Expression
+}
+
+type SubroutineCall struct {
+	Receiver Ident
+	Name     Ident
+	Args     []Expression
+}
+
+func (e *BinaryExpression) Pos() diag.Pos { return e.Left.Pos() }
+func (e *UnaryExpression) Pos() diag.Pos  { return e.Position }
+func (e *ParenExpression) Pos() diag.Pos  { return e.Position }
+func (e *IntegerConstant) Pos() diag.Pos  { return e.Position }
+func (e *StringConstant) Pos() diag.Pos   { return e.Position }
+func (e *KeywordConstant) Pos() diag.Pos  { return e.Position }
+func (e *VarRef) Pos() diag.Pos           { return e.Name.Position }
+func (e *ArrayAccess) Pos() diag.Pos      { return e.Name.Position }
+
+func (e *SubroutineCall) Pos() diag.Pos {
+	if e.Receiver.Name != "" {
+		return e.Receiver.Position
+	}
+	return e.Name.Position
+}
+
+func (*BinaryExpression) expressionNode() {}
+func (*UnaryExpression) expressionNode()  {}
+func (*ParenExpression) expressionNode()  {}
+func (*IntegerConstant) expressionNode()  {}
+func (*StringConstant) expressionNode()   {}
+func (*KeywordConstant) expressionNode()  {}
+func (*VarRef) expressionNode()           {}
+func (*ArrayAccess) expressionNode()      {}
+func (*SubroutineCall) expressionNode()   {}
@@ -1,44 +1,29 @@
 package compengine
 
 import (
+	"compiler/pkg/ast"
 	"compiler/pkg/symtable"
-	"compiler/pkg/tokenizer"
 	"compiler/pkg/vmwriter"
-	"log"
-	"os"
-	"path"
 	"strconv"
-	"strings"
 )
 
 type CompilationEngine struct {
-	tokenizer                *tokenizer.Tokenizer
-	vmWriter                 *vmwriter.VMWriter
-	classSymTable            *symtable.SymbolTable
-	subroutineSymTable       *symtable.SymbolTable
-	isIdentifierDeclaration  bool
-	outputFile               *os.File
-	className                string
-	functionName             string
-	ifLabelCounter           int
-	whileLabelCounter        int
-	isConstructorCompilation bool
-	isMethodCompilation      bool
+	vmWriter           *vmwriter.VMWriter
+	classSymTable      *symtable.SymbolTable
+	subroutineSymTable *symtable.SymbolTable
+	className          string
+	ifLabelCounter     int
+	whileLabelCounter  int
 }
 
-func New(tokenizer *tokenizer.Tokenizer, vmWriter *vmwriter.VMWriter, classSymTable *symtable.SymbolTable, subroutineSymTable *symtable.SymbolTable, outputFile *os.File) *CompilationEngine {
+func New(vmWriter *vmwriter.VMWriter, classSymTable *symtable.SymbolTable, subroutineSymTable *symtable.SymbolTable) *CompilationEngine {
 	return &CompilationEngine{
-		tokenizer:                tokenizer,
-		vmWriter:                 vmWriter,
-		classSymTable:            classSymTable,
-		subroutineSymTable:       subroutineSymTable,
-		outputFile:               outputFile,
-		className:                strings.Split(path.Base(outputFile.Name()), ".")[0],
-		functionName:             "",
-		ifLabelCounter:           -1,
-		whileLabelCounter:        -1,
-		isConstructorCompilation: false,
-		isMethodCompilation:      false,
+		vmWriter:           vmWriter,
+		classSymTable:      classSymTable,
+		subroutineSymTable: subroutineSymTable,
+		className:          "",
+		ifLabelCounter:     -1,
+		whileLabelCounter:  -1,
 	}
 }
 
@@ -61,275 +46,94 @@ func (c *CompilationEngine) getSymbolTable(identifier string) *symtable.SymbolTa
 	return c.classSymTable
 }
 
-func (c *CompilationEngine) getCurrentToken() string {
-	switch c.tokenizer.TokenType() {
-	case tokenizer.Keyword:
-		return c.tokenizer.KeyWord()
-	case tokenizer.Symbol:
-		return c.tokenizer.Symbol()
-	case tokenizer.Identifier:
-		return c.tokenizer.Identifier()
-	case tokenizer.IntConst:
-		return strconv.Itoa(c.tokenizer.IntVal())
-	case tokenizer.StringConst:
-		return c.tokenizer.StringVal()
-	}
-	return ""
-}
-
-func (c *CompilationEngine) process(str string) {
-	if str != c.getCurrentToken() {
-		log.Println("syntax error:", str)
-	}
-	c.tokenizer.Advance()
-}
-
-func (c *CompilationEngine) processCurrentToken() {
-	c.process(c.getCurrentToken())
-}
-
-func (c *CompilationEngine) getIdentifierSymtableOutput(identifier string) string {
-	var category string
-	entryKind := c.getSymbolTable(identifier).KindOf(identifier)
-	switch entryKind {
-	case symtable.Static:
-		category = "static"
-	case symtable.Field:
-		category = "field"
-	case symtable.Arg:
-		category = "arg"
-	case symtable.Var:
-		category = "var"
-	case symtable.None:
-		if identifier == c.className {
-			category = "class"
-		} else {
-			category = "subroutine"
+func (c *CompilationEngine) CompileClass(class *ast.Class) {
+	c.classSymTable.Reset()
+	c.className = class.Name.Name
+	for _, varDec := range class.VarDecs {
+		kind := symtable.Static
+		if varDec.Kind == "field" {
+			kind = symtable.Field
 		}
-	}
-
-	output := []string{identifier, category}
-	if entryKind != symtable.None {
-		output = append(output, strconv.Itoa(c.getSymbolTable(identifier).IndexOf(identifier)))
-
-		if c.isIdentifierDeclaration {
-			output = append(output, "declared")
-		} else {
-			output = append(output, "used")
+		for _, name := range varDec.Names {
+			c.classSymTable.Define(name.Name, varDec.Type.Name, kind)
 		}
 	}
-	return strings.Join(output, " - ")
-}
-
-func createXMLToken(tokenName, value string) string {
-	sanitizedValue := value
-	if value == "<" {
-		sanitizedValue = "&lt;"
-	} else if value == ">" {
-		sanitizedValue = "&gt;"
-	} else if value == "&" {
-		sanitizedValue = "&amp;"
-	} else if value == "\"" {
-		sanitizedValue = "&quot;"
+	for _, subroutine := range class.Subroutines {
+		c.CompileSubroutine(subroutine)
 	}
-
-	var sb strings.Builder
-	sb.WriteString("<" + tokenName + ">")
-	sb.WriteString(" " + sanitizedValue + " ")
-	sb.WriteString("</" + tokenName + ">\n")
-	return sb.String()
 }
 
-func (c *CompilationEngine) CompileClass() {
-	c.process("class")
-	c.processCurrentToken()
-	c.process("{")
-	for c.getCurrentToken() == "static" || c.getCurrentToken() == "field" {
-		c.CompileClassVarDec()
-	}
-	for c.getCurrentToken() == "constructor" || c.getCurrentToken() == "function" || c.getCurrentToken() == "method" {
-		c.CompileSubroutine()
-	}
-	c.process("}")
-}
-
-func (c *CompilationEngine) CompileClassVarDec() {
-	var kind symtable.SymbolTableEntryKind
-	if c.getCurrentToken() == "static" {
-		c.process("static")
-		kind = symtable.Static
-	} else {
-		c.process("field")
-		kind = symtable.Field
-	}
-	entryType := c.getCurrentToken()
-	c.processCurrentToken()
-	c.isIdentifierDeclaration = true
-	c.classSymTable.Define(c.getCurrentToken(), entryType, kind)
-	c.processCurrentToken()
-	for c.getCurrentToken() == "," {
-		c.process(",")
-		c.classSymTable.Define(c.getCurrentToken(), entryType, kind)
-		c.processCurrentToken()
-	}
-	c.process(";")
-	c.isIdentifierDeclaration = false
-}
-
-func (c *CompilationEngine) CompileSubroutine() {
+func (c *CompilationEngine) CompileSubroutine(subroutine *ast.SubroutineDec) {
 	c.subroutineSymTable.Reset()
 	c.ifLabelCounter = -1
 	c.whileLabelCounter = -1
-	c.isConstructorCompilation = false
-	c.isMethodCompilation = false
-	if c.getCurrentToken() == "constructor" {
-		c.isConstructorCompilation = true
-		c.process("constructor")
-	} else if c.getCurrentToken() == "function" {
-		c.process("function")
-	} else {
-		c.isMethodCompilation = true
-		c.process("method")
+	if subroutine.Kind == "method" {
+		c.subroutineSymTable.Define("this", c.className, symtable.Arg)
 	}
-	isVoidSubroutine := false
-	if c.getCurrentToken() == "void" {
-		isVoidSubroutine = true
-		c.process("void")
-	} else {
-		c.processCurrentToken()
-	}
-	c.functionName = c.className + "." + c.getCurrentToken()
-	c.processCurrentToken()
-	c.process("(")
-	c.CompileParameterList()
-	c.process(")")
-	c.CompileSubroutineBody()
-	if isVoidSubroutine {
-		c.vmWriter.WritePush(vmwriter.Constant, 0)
-		c.vmWriter.WriteReturn()
+	for _, param := range subroutine.Params {
+		c.subroutineSymTable.Define(param.Name.Name, param.Type.Name, symtable.Arg)
 	}
-	c.isConstructorCompilation = false
-}
-
-func (c *CompilationEngine) CompileParameterList() {
-	token := c.getCurrentToken()
-	isBuiltInType := token == "int" || token == "char" || token == "boolean"
-	c.isIdentifierDeclaration = true
-	if isBuiltInType || c.tokenizer.TokenType() == tokenizer.Identifier {
-		entryType := c.getCurrentToken()
-		c.processCurrentToken()
-		c.subroutineSymTable.Define(c.getCurrentToken(), entryType, symtable.Arg)
-		c.processCurrentToken()
-	}
-	for c.getCurrentToken() == "," {
-		c.process(",")
-		entryType := c.getCurrentToken()
-		c.processCurrentToken()
-		c.subroutineSymTable.Define(c.getCurrentToken(), entryType, symtable.Arg)
-		c.processCurrentToken()
+	for _, varDec := range subroutine.VarDecs {
+		for _, name := range varDec.Names {
+			c.subroutineSymTable.Define(name.Name, varDec.Type.Name, symtable.Var)
+		}
 	}
-	c.isIdentifierDeclaration = false
-}
 
-func (c *CompilationEngine) CompileSubroutineBody() {
-	c.process("{")
-	for c.getCurrentToken() == "var" {
-		c.CompileVarDec()
-	}
-	c.vmWriter.WriteFunction(c.functionName, c.subroutineSymTable.VarCount(symtable.Var))
-	if c.isMethodCompilation {
+	c.vmWriter.WriteFunction(c.className+"."+subroutine.Name.Name, c.subroutineSymTable.VarCount(symtable.Var))
+	if subroutine.Kind == "method" {
 		c.vmWriter.WritePush(vmwriter.Argument, 0)
 		c.vmWriter.WritePop(vmwriter.Pointer, 0)
-	} else if c.isConstructorCompilation {
+	} else if subroutine.Kind == "constructor" {
 		c.vmWriter.WritePush(vmwriter.Constant, c.classSymTable.VarCount(symtable.Field))
 		c.vmWriter.WriteCall("Memory.alloc", 1)
 		c.vmWriter.WritePop(vmwriter.Pointer, 0)
 	}
-	c.CompileStatements()
-	c.process("}")
+	c.CompileStatements(subroutine.Statements)
 }
 
-func (c *CompilationEngine) CompileVarDec() {
-	c.process("var")
-	entryType := c.getCurrentToken()
-	c.processCurrentToken()
-	c.isIdentifierDeclaration = true
-	c.subroutineSymTable.Define(c.getCurrentToken(), entryType, symtable.Var)
-	c.processCurrentToken()
-	for c.getCurrentToken() == "," {
-		c.process(",")
-		c.subroutineSymTable.Define(c.getCurrentToken(), entryType, symtable.Var)
-		c.processCurrentToken()
-	}
-	c.process(";")
-	c.isIdentifierDeclaration = false
-}
-
-func (c *CompilationEngine) CompileStatements() {
-	stop := false
-	for !stop {
-		switch c.getCurrentToken() {
-		case "let":
-			c.CompileLet()
-		case "if":
-			c.CompileIf()
-		case "while":
-			c.CompileWhile()
-		case "do":
-			c.compileDo()
-		case "return":
-			c.CompileReturn()
-		default:
-			stop = true
+func (c *CompilationEngine) CompileStatements(statements []ast.Statement) {
+	for _, statement := range statements {
+		switch s := statement.(type) {
+		case *ast.LetStatement:
+			c.CompileLet(s)
+		case *ast.IfStatement:
+			c.CompileIf(s)
+		case *ast.WhileStatement:
+			c.CompileWhile(s)
+		case *ast.DoStatement:
+			c.compileDo(s)
+		case *ast.ReturnStatement:
+			c.CompileReturn(s)
 		}
 	}
 }
 
-func (c *CompilationEngine) CompileLet() {
-	c.process("let")
-	varName := c.getCurrentToken()
-	c.processCurrentToken()
-	isArrayAssignment := false
-	if c.getCurrentToken() == "[" {
-		isArrayAssignment = true
-		c.process("[")
-		c.CompileExpression()
+func (c *CompilationEngine) CompileLet(statement *ast.LetStatement) {
+	varName := statement.Name.Name
+	if statement.Index != nil {
+		c.CompileExpression(statement.Index)
 		c.writePushForIdentifier(varName)
 		c.vmWriter.WriteArithmetic(vmwriter.Add)
-		c.process("]")
-	}
-	c.process("=")
-	c.CompileExpression()
-	if isArrayAssignment {
+		c.CompileExpression(statement.Value)
 		c.vmWriter.WritePop(vmwriter.Temp, 0)
 		c.vmWriter.WritePop(vmwriter.Pointer, 1)
 		c.vmWriter.WritePush(vmwriter.Temp, 0)
 		c.vmWriter.WritePop(vmwriter.That, 0)
 	} else {
+		c.CompileExpression(statement.Value)
 		c.writePopForIdentifier(varName)
 	}
-	c.process(";")
 }
 
 func (c *CompilationEngine) writePopForIdentifier(identifier string) {
 	if segment, found := c.getMemorySegment(identifier); found {
-		symTable := c.getSymbolTable(identifier)
-		index := symTable.IndexOf(identifier)
-		if symTable.KindOf(identifier) == symtable.Arg && c.isMethodCompilation {
-			index++
-		}
 		c.vmWriter.WritePop(segment, c.getSymbolTable(identifier).IndexOf(identifier))
 	}
 }
 
 func (c *CompilationEngine) writePushForIdentifier(identifier string) {
 	if segment, found := c.getMemorySegment(identifier); found {
-		symTable := c.getSymbolTable(identifier)
-		index := symTable.IndexOf(identifier)
-		if symTable.KindOf(identifier) == symtable.Arg && c.isMethodCompilation {
-			index++
-		}
-		c.vmWriter.WritePush(segment, index)
+		c.vmWriter.WritePush(segment, c.getSymbolTable(identifier).IndexOf(identifier))
 	}
 }
 
@@ -348,213 +152,134 @@ func (c *CompilationEngine) getMemorySegment(identifier string) (segment vmwrite
 	return -1, false
 }
 
-func (c *CompilationEngine) CompileIf() {
+func (c *CompilationEngine) CompileIf(statement *ast.IfStatement) {
 	lt, lf, le := c.nextUniqueIfLabelTuple()
-	c.process("if")
-	c.process("(")
-	c.CompileExpression()
+	c.CompileExpression(statement.Condition)
 	c.vmWriter.WriteIf(lt)
 	c.vmWriter.WriteGoto(lf)
 	c.vmWriter.WriteLabel(lt)
-	c.process(")")
-	c.process("{")
-	c.CompileStatements()
-	c.process("}")
-	if c.getCurrentToken() == "else" {
+	c.CompileStatements(statement.Then)
+	if statement.HasElse {
 		c.vmWriter.WriteGoto(le)
 		c.vmWriter.WriteLabel(lf)
-		c.process("else")
-		c.process("{")
-		c.CompileStatements()
-		c.process("}")
+		c.CompileStatements(statement.Else)
 		c.vmWriter.WriteLabel(le)
 	} else {
 		c.vmWriter.WriteLabel(lf)
 	}
 }
 
-func (c *CompilationEngine) CompileWhile() {
-	c.process("while")
-	c.process("(")
+func (c *CompilationEngine) CompileWhile(statement *ast.WhileStatement) {
 	l1, l2 := c.nextUniqueWhileLabelTuple()
 	c.vmWriter.WriteLabel(l1)
-	c.CompileExpression()
+	c.CompileExpression(statement.Condition)
 	c.vmWriter.WriteArithmetic(vmwriter.Not)
 	c.vmWriter.WriteIf(l2)
-	c.process(")")
-	c.process("{")
-	c.CompileStatements()
-	c.process("}")
+	c.CompileStatements(statement.Body)
 	c.vmWriter.WriteGoto(l1)
 	c.vmWriter.WriteLabel(l2)
 }
 
-func (c *CompilationEngine) compileDo() {
-	c.process("do")
-	functionName := c.getCurrentToken()
-	var nArgs int
-	c.processCurrentToken()
-	if c.getCurrentToken() == "(" {
-		c.process("(")
-		functionName = c.className + "." + functionName
-		c.vmWriter.WritePush(vmwriter.Pointer, 0)
-		nArgs = c.CompileExpressionList() + 1
-		c.process(")")
-	} else {
-		c.process(".")
-		symTable := c.getSymbolTable(functionName)
-		switch symTable.KindOf(functionName) {
-		case symtable.Var:
-			c.vmWriter.WritePush(vmwriter.Local, symTable.IndexOf(functionName))
-			nArgs = 1
-			functionName = symTable.TypeOf(functionName) + "." + c.getCurrentToken()
-		case symtable.Field:
-			c.vmWriter.WritePush(vmwriter.This, symTable.IndexOf(functionName))
-			nArgs = 1
-			functionName = symTable.TypeOf(functionName) + "." + c.getCurrentToken()
-		default:
-			functionName = functionName + "." + c.getCurrentToken()
-		}
-		c.processCurrentToken()
-		c.process("(")
-		nArgs += c.CompileExpressionList()
-		c.process(")")
-	}
-	c.process(";")
-	c.vmWriter.WriteCall(functionName, nArgs)
+func (c *CompilationEngine) compileDo(statement *ast.DoStatement) {
+	c.compileSubroutineCall(statement.Call)
 	c.vmWriter.WritePop(vmwriter.Temp, 0)
 }
 
-func (c *CompilationEngine) CompileReturn() {
-	c.process("return")
-	if c.getCurrentToken() != ";" {
-		c.CompileExpression()
-		c.vmWriter.WriteReturn()
+func (c *CompilationEngine) compileSubroutineCall(call *ast.SubroutineCall) {
+	var className string
+	nArgs := len(call.Args)
+	if call.Receiver.Name == "" {
+		c.vmWriter.WritePush(vmwriter.Pointer, 0)
+		className = c.className
+		nArgs++
+	} else if symTable := c.getSymbolTable(call.Receiver.Name); symTable.KindOf(call.Receiver.Name) != symtable.None {
+		c.writePushForIdentifier(call.Receiver.Name)
+		className = symTable.TypeOf(call.Receiver.Name)
+		nArgs++
+	} else {
+		className = call.Receiver.Name
 	}
-	c.process(";")
-}
-
-func (c *CompilationEngine) CompileExpression() {
-	c.CompileTerm()
-	for isOp(c.getCurrentToken()) {
-		operator := c.getCurrentToken()
-		c.processCurrentToken()
-		c.CompileTerm()
-		switch operator {
-		case "+":
-			c.vmWriter.WriteArithmetic(vmwriter.Add)
-		case "-":
-			c.vmWriter.WriteArithmetic(vmwriter.Sub)
-		case "*":
-			c.vmWriter.WriteCall("Math.multiply", 2)
-		case "/":
-			c.vmWriter.WriteCall("Math.divide", 2)
-		case "<":
-			c.vmWriter.WriteArithmetic(vmwriter.Lt)
-		case ">":
-			c.vmWriter.WriteArithmetic(vmwriter.Gt)
-		case "=":
-			c.vmWriter.WriteArithmetic(vmwriter.Eq)
-		case "&":
-			c.vmWriter.WriteArithmetic(vmwriter.And)
-		case "|":
-			c.vmWriter.WriteArithmetic(vmwriter.Or)
-		}
+	for _, arg := range call.Args {
+		c.CompileExpression(arg)
 	}
+	c.vmWriter.WriteCall(className+"."+call.Name.Name, nArgs)
 }
 
-func isOp(token string) bool {
-	operators := []string{"+", "-", "*", "/", "&", "|", "<", ">", "="}
-	for _, op := range operators {
-		if token == op {
-			return true
-		}
+func (c *CompilationEngine) CompileReturn(statement *ast.ReturnStatement) {
+	if statement.Value != nil {
+		c.CompileExpression(statement.Value)
+	} else {
+		c.vmWriter.WritePush(vmwriter.Constant, 0)
 	}
-	return false
+	c.vmWriter.WriteReturn()
 }
 
-func (c *CompilationEngine) CompileTerm() {
-	if c.getCurrentToken() == "(" {
-		c.process("(")
-		c.CompileExpression()
-		c.process(")")
-	} else if c.getCurrentToken() == "-" {
-		c.processCurrentToken()
-		c.CompileTerm()
-		c.vmWriter.WriteArithmetic(vmwriter.Neg)
-	} else if c.getCurrentToken() == "~" {
-		c.processCurrentToken()
-		c.CompileTerm()
-		c.vmWriter.WriteArithmetic(vmwriter.Not)
-	} else {
-		tokenType := c.tokenizer.TokenType()
-		switch tokenType {
-		case tokenizer.IntConst:
-			c.vmWriter.WritePush(vmwriter.Constant, c.tokenizer.IntVal())
-		case tokenizer.StringConst:
-			c.vmWriter.WritePush(vmwriter.Constant, len(c.tokenizer.StringVal()))
-			c.vmWriter.WriteCall("String.new", 1)
-			for _, char := range c.tokenizer.StringVal() {
-				c.vmWriter.WritePush(vmwriter.Constant, int(char))
-				c.vmWriter.WriteCall("String.appendChar", 2)
-			}
+func (c *CompilationEngine) CompileExpression(expression ast.Expression) {
+	switch e := expression.(type) {
+	case *ast.BinaryExpression:
+		c.CompileExpression(e.Left)
+		c.CompileExpression(e.Right)
+		c.compileOperator(e.Operator)
+	case *ast.UnaryExpression:
+		c.CompileExpression(e.Operand)
+		if e.Operator == "-" {
+			c.vmWriter.WriteArithmetic(vmwriter.Neg)
+		} else {
+			c.vmWriter.WriteArithmetic(vmwriter.Not)
+		}
+	case *ast.ParenExpression:
+		c.CompileExpression(e.Inner)
+	case *ast.IntegerConstant:
+		c.vmWriter.WritePush(vmwriter.Constant, e.Value)
+	case *ast.StringConstant:
+		c.vmWriter.WritePush(vmwriter.Constant, len(e.Value))
+		c.vmWriter.WriteCall("String.new", 1)
+		for _, char := range e.Value {
+			c.vmWriter.WritePush(vmwriter.Constant, int(char))
+			c.vmWriter.WriteCall("String.appendChar", 2)
 		}
-		if c.getCurrentToken() == "true" {
+	case *ast.KeywordConstant:
+		switch e.Value {
+		case "true":
 			c.vmWriter.WritePush(vmwriter.Constant, 0)
 			c.vmWriter.WriteArithmetic(vmwriter.Not)
-		} else if c.getCurrentToken() == "null" || c.getCurrentToken() == "false" {
+		case "false", "null":
 			c.vmWriter.WritePush(vmwriter.Constant, 0)
-		} else if c.getCurrentToken() == "this" {
+		case "this":
 			c.vmWriter.WritePush(vmwriter.Pointer, 0)
 		}
-
-		identifier := c.getCurrentToken()
-		c.processCurrentToken()
-		if c.getCurrentToken() == "[" {
-			c.process("[")
-			c.CompileExpression()
-			c.writePushForIdentifier(identifier)
-			c.vmWriter.WriteArithmetic(vmwriter.Add)
-			c.vmWriter.WritePop(vmwriter.Pointer, 1)
-			c.vmWriter.WritePush(vmwriter.That, 0)
-			c.process("]")
-		} else if c.getCurrentToken() == "(" {
-			c.process("(")
-			c.CompileExpressionList()
-			c.process(")")
-		} else if c.getCurrentToken() == "." {
-			c.process(".")
-			var classFunctionName string
-			nArgs := 0
-			symTable := c.getSymbolTable(identifier)
-			if symTable.KindOf(identifier) == symtable.Field {
-				c.writePushForIdentifier(identifier)
-				classFunctionName = symTable.TypeOf(identifier) + "." + c.getCurrentToken()
-				nArgs++
-			} else {
-				classFunctionName = identifier + "." + c.getCurrentToken()
-			}
-			c.processCurrentToken()
-			c.process("(")
-			nArgs += c.CompileExpressionList()
-			c.vmWriter.WriteCall(classFunctionName, nArgs)
-			c.process(")")
-		} else if tokenType == tokenizer.Identifier {
-			c.writePushForIdentifier(identifier)
-		}
+	case *ast.VarRef:
+		c.writePushForIdentifier(e.Name.Name)
+	case *ast.ArrayAccess:
+		c.CompileExpression(e.Index)
+		c.writePushForIdentifier(e.Name.Name)
+		c.vmWriter.WriteArithmetic(vmwriter.Add)
+		c.vmWriter.WritePop(vmwriter.Pointer, 1)
+		c.vmWriter.WritePush(vmwriter.That, 0)
+	case *ast.SubroutineCall:
+		c.compileSubroutineCall(e)
 	}
 }
 
-func (c *CompilationEngine) CompileExpressionList() int {
-	if c.getCurrentToken() == ")" {
-		return 0
-	}
-	c.CompileExpression()
-	i := 1
-	for c.getCurrentToken() == "," {
-		c.process(",")
-		c.CompileExpression()
-		i++
+func (c *CompilationEngine) compileOperator(operator string) {
+	switch operator {
+	case "+":
+		c.vmWriter.WriteArithmetic(vmwriter.Add)
+	case "-":
+		c.vmWriter.WriteArithmetic(vmwriter.Sub)
+	case "*":
+		c.vmWriter.WriteCall("Math.multiply", 2)
+	case "/":
+		c.vmWriter.WriteCall("Math.divide", 2)
+	case "<":
+		c.vmWriter.WriteArithmetic(vmwriter.Lt)
+	case ">":
+		c.vmWriter.WriteArithmetic(vmwriter.Gt)
+	case "=":
+		c.vmWriter.WriteArithmetic(vmwriter.Eq)
+	case "&":
+		c.vmWriter.WriteArithmetic(vmwriter.And)
+	case "|":
+		c.vmWriter.WriteArithmetic(vmwriter.Or)
 	}
-	return i
 }
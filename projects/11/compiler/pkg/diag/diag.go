@@ -0,0 +1,50 @@
+package diag
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type Pos struct {
+	File string
+	Line int
+	Col  int
+}
+
+func (p Pos) String() string {
+	return p.File + ":" + strconv.Itoa(p.Line) + ":" + strconv.Itoa(p.Col)
+}
+
+type Diagnostic struct {
+	Pos Pos
+	Msg string
+}
+
+func (d Diagnostic) Error() string {
+	return d.Pos.String() + ": " + d.Msg
+}
+
+type Reporter struct {
+	diagnostics []Diagnostic
+}
+
+func NewReporter() *Reporter {
+	return &Reporter{
+		diagnostics: []Diagnostic{},
+	}
+}
+
+func (r *Reporter) Errorf(pos Pos, format string, args ...any) {
+	r.diagnostics = append(r.diagnostics, Diagnostic{
+		Pos: pos,
+		Msg: fmt.Sprintf(format, args...),
+	})
+}
+
+func (r *Reporter) HasErrors() bool {
+	return len(r.diagnostics) > 0
+}
+
+func (r *Reporter) Diagnostics() []Diagnostic {
+	return r.diagnostics
+}
@@ -0,0 +1,393 @@
+package parser
+
+import (
+	"compiler/pkg/ast"
+	"compiler/pkg/diag"
+	"compiler/pkg/tokenizer"
+	"strconv"
+)
+
+type Parser struct {
+	tokenizer   *tokenizer.Tokenizer
+	reporter    *diag.Reporter
+	isPanicMode bool
+}
+
+func New(tokenizer *tokenizer.Tokenizer, reporter *diag.Reporter) *Parser {
+	return &Parser{
+		tokenizer:   tokenizer,
+		reporter:    reporter,
+		isPanicMode: false,
+	}
+}
+
+func (p *Parser) getCurrentToken() string {
+	switch p.tokenizer.TokenType() {
+	case tokenizer.Keyword:
+		return p.tokenizer.KeyWord()
+	case tokenizer.Symbol:
+		return p.tokenizer.Symbol()
+	case tokenizer.Identifier:
+		return p.tokenizer.Identifier()
+	case tokenizer.IntConst:
+		return strconv.Itoa(p.tokenizer.IntVal())
+	case tokenizer.StringConst:
+		return "\"" + p.tokenizer.StringVal() + "\""
+	}
+	return ""
+}
+
+func (p *Parser) describeCurrentToken() string {
+	if p.tokenizer.AtEnd() {
+		return "end of file"
+	}
+	return strconv.Quote(p.getCurrentToken())
+}
+
+func (p *Parser) syntaxError(format string, args ...any) {
+	if !p.isPanicMode {
+		p.reporter.Errorf(p.tokenizer.Pos(), "syntax error: "+format, args...)
+		p.isPanicMode = true
+	}
+}
+
+func (p *Parser) process(str string) {
+	if p.tokenizer.AtEnd() || str != p.getCurrentToken() {
+		p.syntaxError("expected %q, found %s", str, p.describeCurrentToken())
+		return
+	}
+	p.tokenizer.Advance()
+}
+
+func (p *Parser) processCurrentToken() {
+	if p.tokenizer.AtEnd() {
+		p.syntaxError("unexpected end of file")
+		return
+	}
+	p.tokenizer.Advance()
+}
+
+func (p *Parser) processIdentifier() ast.Ident {
+	ident := ast.Ident{Name: p.getCurrentToken(), Position: p.tokenizer.Pos()}
+	if p.tokenizer.AtEnd() || p.tokenizer.TokenType() != tokenizer.Identifier {
+		p.syntaxError("expected identifier, found %s", p.describeCurrentToken())
+		return ast.Ident{Position: ident.Position}
+	}
+	p.tokenizer.Advance()
+	return ident
+}
+
+func (p *Parser) processType() ast.Ident {
+	ident := ast.Ident{Name: p.getCurrentToken(), Position: p.tokenizer.Pos()}
+	isBuiltInType := ident.Name == "int" || ident.Name == "char" || ident.Name == "boolean"
+	if !isBuiltInType && (p.tokenizer.AtEnd() || p.tokenizer.TokenType() != tokenizer.Identifier) {
+		p.syntaxError("expected type, found %s", p.describeCurrentToken())
+		return ast.Ident{Position: ident.Position}
+	}
+	p.tokenizer.Advance()
+	return ident
+}
+
+func (p *Parser) synchronize(syncTokens ...string) {
+	for p.isPanicMode && !p.tokenizer.AtEnd() {
+		for _, syncToken := range syncTokens {
+			if p.getCurrentToken() == syncToken {
+				p.isPanicMode = false
+				return
+			}
+		}
+		p.tokenizer.Advance()
+	}
+}
+
+func (p *Parser) synchronizeStatement() {
+	for p.isPanicMode && !p.tokenizer.AtEnd() {
+		switch p.getCurrentToken() {
+		case "let", "do", "if", "while", "return", "}":
+			p.isPanicMode = false
+		case ";":
+			p.tokenizer.Advance()
+			p.isPanicMode = false
+		case "constructor", "function", "method":
+			return
+		default:
+			p.tokenizer.Advance()
+		}
+	}
+}
+
+func (p *Parser) ParseClass() *ast.Class {
+	class := &ast.Class{Position: p.tokenizer.Pos()}
+	p.process("class")
+	class.Name = p.processIdentifier()
+	p.process("{")
+	p.synchronize("static", "field", "constructor", "function", "method")
+	for p.getCurrentToken() == "static" || p.getCurrentToken() == "field" {
+		class.VarDecs = append(class.VarDecs, p.parseClassVarDec())
+		p.synchronize("static", "field", "constructor", "function", "method")
+	}
+	for p.getCurrentToken() == "constructor" || p.getCurrentToken() == "function" || p.getCurrentToken() == "method" {
+		class.Subroutines = append(class.Subroutines, p.parseSubroutine())
+		p.synchronize("constructor", "function", "method")
+	}
+	p.process("}")
+	if !p.tokenizer.AtEnd() {
+		p.syntaxError("expected end of file, found %s", p.describeCurrentToken())
+	}
+	return class
+}
+
+func (p *Parser) parseClassVarDec() *ast.ClassVarDec {
+	varDec := &ast.ClassVarDec{Kind: p.getCurrentToken(), Position: p.tokenizer.Pos()}
+	p.processCurrentToken()
+	varDec.Type = p.processType()
+	varDec.Names = append(varDec.Names, p.processIdentifier())
+	for p.getCurrentToken() == "," {
+		p.process(",")
+		varDec.Names = append(varDec.Names, p.processIdentifier())
+	}
+	p.process(";")
+	return varDec
+}
+
+func (p *Parser) parseSubroutine() *ast.SubroutineDec {
+	subroutine := &ast.SubroutineDec{Kind: p.getCurrentToken(), Position: p.tokenizer.Pos()}
+	p.processCurrentToken()
+	if p.getCurrentToken() == "void" {
+		subroutine.ReturnType = ast.Ident{Name: "void", Position: p.tokenizer.Pos()}
+		p.process("void")
+	} else {
+		subroutine.ReturnType = p.processType()
+	}
+	subroutine.Name = p.processIdentifier()
+	p.process("(")
+	subroutine.Params = p.parseParameterList()
+	p.process(")")
+	p.process("{")
+	for p.getCurrentToken() == "var" {
+		subroutine.VarDecs = append(subroutine.VarDecs, p.parseVarDec())
+	}
+	subroutine.Statements = p.parseStatements()
+	p.process("}")
+	return subroutine
+}
+
+func (p *Parser) parseParameterList() []*ast.Param {
+	params := []*ast.Param{}
+	token := p.getCurrentToken()
+	isBuiltInType := token == "int" || token == "char" || token == "boolean"
+	if isBuiltInType || p.tokenizer.TokenType() == tokenizer.Identifier {
+		params = append(params, p.parseParameter())
+	}
+	for p.getCurrentToken() == "," {
+		p.process(",")
+		params = append(params, p.parseParameter())
+	}
+	return params
+}
+
+func (p *Parser) parseParameter() *ast.Param {
+	paramType := p.processType()
+	return &ast.Param{Type: paramType, Name: p.processIdentifier()}
+}
+
+func (p *Parser) parseVarDec() *ast.VarDec {
+	varDec := &ast.VarDec{Position: p.tokenizer.Pos()}
+	p.process("var")
+	varDec.Type = p.processType()
+	varDec.Names = append(varDec.Names, p.processIdentifier())
+	for p.getCurrentToken() == "," {
+		p.process(",")
+		varDec.Names = append(varDec.Names, p.processIdentifier())
+	}
+	p.process(";")
+	return varDec
+}
+
+func (p *Parser) parseStatements() []ast.Statement {
+	statements := []ast.Statement{}
+	for {
+		p.synchronizeStatement()
+		switch p.getCurrentToken() {
+		case "let":
+			statements = append(statements, p.parseLet())
+		case "if":
+			statements = append(statements, p.parseIf())
+		case "while":
+			statements = append(statements, p.parseWhile())
+		case "do":
+			statements = append(statements, p.parseDo())
+		case "return":
+			statements = append(statements, p.parseReturn())
+		default:
+			return statements
+		}
+	}
+}
+
+func (p *Parser) parseLet() *ast.LetStatement {
+	statement := &ast.LetStatement{Position: p.tokenizer.Pos()}
+	p.process("let")
+	statement.Name = p.processIdentifier()
+	if p.getCurrentToken() == "[" {
+		p.process("[")
+		statement.Index = p.parseExpression()
+		p.process("]")
+	}
+	p.process("=")
+	statement.Value = p.parseExpression()
+	p.process(";")
+	return statement
+}
+
+func (p *Parser) parseIf() *ast.IfStatement {
+	statement := &ast.IfStatement{Position: p.tokenizer.Pos()}
+	p.process("if")
+	p.process("(")
+	statement.Condition = p.parseExpression()
+	p.process(")")
+	p.process("{")
+	statement.Then = p.parseStatements()
+	p.process("}")
+	if p.getCurrentToken() == "else" {
+		statement.HasElse = true
+		p.process("else")
+		p.process("{")
+		statement.Else = p.parseStatements()
+		p.process("}")
+	}
+	return statement
+}
+
+func (p *Parser) parseWhile() *ast.WhileStatement {
+	statement := &ast.WhileStatement{Position: p.tokenizer.Pos()}
+	p.process("while")
+	p.process("(")
+	statement.Condition = p.parseExpression()
+	p.process(")")
+	p.process("{")
+	statement.Body = p.parseStatements()
+	p.process("}")
+	return statement
+}
+
+func (p *Parser) parseDo() *ast.DoStatement {
+	statement := &ast.DoStatement{Position: p.tokenizer.Pos()}
+	p.process("do")
+	statement.Call = p.parseSubroutineCall(p.processIdentifier())
+	p.process(";")
+	return statement
+}
+
+func (p *Parser) parseSubroutineCall(name ast.Ident) *ast.SubroutineCall {
+	call := &ast.SubroutineCall{Name: name}
+	if p.getCurrentToken() != "(" {
+		p.process(".")
+		call.Receiver = name
+		call.Name = p.processIdentifier()
+	}
+	p.process("(")
+	call.Args = p.parseExpressionList()
+	p.process(")")
+	return call
+}
+
+func (p *Parser) parseReturn() *ast.ReturnStatement {
+	statement := &ast.ReturnStatement{Position: p.tokenizer.Pos()}
+	p.process("return")
+	if p.getCurrentToken() != ";" {
+		statement.Value = p.parseExpression()
+	}
+	p.process(";")
+	return statement
+}
+
+func isOp(token string) bool {
+	operators := []string{"+", "-", "*", "/", "&", "|", "<", ">", "="}
+	for _, op := range operators {
+		if token == op {
+			return true
+		}
+	}
+	return false
+}
+
+func isKeywordConstant(token string) bool {
+	return token == "true" || token == "false" || token == "null" || token == "this"
+}
+
+func (p *Parser) parseExpression() ast.Expression {
+	expression := p.parseTerm()
+	for isOp(p.getCurrentToken()) {
+		binaryExpression := &ast.BinaryExpression{
+			Operator:    p.getCurrentToken(),
+			OperatorPos: p.tokenizer.Pos(),
+			Left:        expression,
+		}
+		p.processCurrentToken()
+		binaryExpression.Right = p.parseTerm()
+		expression = binaryExpression
+	}
+	return expression
+}
+
+func (p *Parser) parseTerm() ast.Expression {
+	pos := p.tokenizer.Pos()
+	if p.getCurrentToken() == "(" {
+		p.process("(")
+		expression := &ast.ParenExpression{Inner: p.parseExpression(), Position: pos}
+		p.process(")")
+		return expression
+	}
+	if p.getCurrentToken() == "-" || p.getCurrentToken() == "~" {
+		operator := p.getCurrentToken()
+		p.processCurrentToken()
+		return &ast.UnaryExpression{Operator: operator, Operand: p.parseTerm(), Position: pos}
+	}
+
+	tokenType := p.tokenizer.TokenType()
+	if p.tokenizer.AtEnd() || tokenType == tokenizer.Symbol || (tokenType == tokenizer.Keyword && !isKeywordConstant(p.getCurrentToken())) {
+		p.syntaxError("expected expression, found %s", p.describeCurrentToken())
+		return &ast.KeywordConstant{Value: "null", Position: pos}
+	}
+
+	switch tokenType {
+	case tokenizer.IntConst:
+		expression := &ast.IntegerConstant{Value: p.tokenizer.IntVal(), Position: pos}
+		p.processCurrentToken()
+		return expression
+	case tokenizer.StringConst:
+		expression := &ast.StringConstant{Value: p.tokenizer.StringVal(), Position: pos}
+		p.processCurrentToken()
+		return expression
+	case tokenizer.Keyword:
+		expression := &ast.KeywordConstant{Value: p.getCurrentToken(), Position: pos}
+		p.processCurrentToken()
+		return expression
+	}
+
+	name := p.processIdentifier()
+	if p.getCurrentToken() == "[" {
+		p.process("[")
+		expression := &ast.ArrayAccess{Name: name, Index: p.parseExpression()}
+		p.process("]")
+		return expression
+	}
+	if p.getCurrentToken() == "(" || p.getCurrentToken() == "." {
+		return p.parseSubroutineCall(name)
+	}
+	return &ast.VarRef{Name: name}
+}
+
+func (p *Parser) parseExpressionList() []ast.Expression {
+	expressions := []ast.Expression{}
+	if p.getCurrentToken() == ")" {
+		return expressions
+	}
+	expressions = append(expressions, p.parseExpression())
+	for p.getCurrentToken() == "," {
+		p.process(",")
+		expressions = append(expressions, p.parseExpression())
+	}
+	return expressions
+}
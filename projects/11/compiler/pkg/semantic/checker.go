@@ -0,0 +1,265 @@
+package semantic
+
+import (
+	"compiler/pkg/ast"
+	"compiler/pkg/diag"
+	"compiler/pkg/symtable"
+)
+
+type callTarget int
+
+const (
+	thisCall callTarget = iota
+	objectCall
+	classCall
+)
+
+type Checker struct {
+	program            *Program
+	reporter           *diag.Reporter
+	isStrict           bool
+	classSymTable      *symtable.SymbolTable
+	subroutineSymTable *symtable.SymbolTable
+	className          string
+	subroutine         *ast.SubroutineDec
+}
+
+func NewChecker(program *Program, reporter *diag.Reporter) *Checker {
+	return &Checker{
+		program:            program,
+		reporter:           reporter,
+		isStrict:           false,
+		classSymTable:      symtable.New(),
+		subroutineSymTable: symtable.New(),
+		className:          "",
+		subroutine:         nil,
+	}
+}
+
+func (c *Checker) SetStrict(isStrict bool) {
+	c.isStrict = isStrict
+}
+
+func (c *Checker) getSymbolTable(identifier string) *symtable.SymbolTable {
+	if c.subroutineSymTable.KindOf(identifier) != symtable.None {
+		return c.subroutineSymTable
+	}
+	return c.classSymTable
+}
+
+func (c *Checker) CheckClass(class *ast.Class) {
+	c.classSymTable.Reset()
+	c.className = class.Name.Name
+	for _, varDec := range class.VarDecs {
+		kind := symtable.Static
+		if varDec.Kind == "field" {
+			kind = symtable.Field
+		}
+		c.checkType(varDec.Type)
+		for _, name := range varDec.Names {
+			c.declare(c.classSymTable, name, varDec.Type.Name, kind)
+		}
+	}
+	for _, subroutine := range class.Subroutines {
+		c.checkSubroutine(subroutine)
+	}
+}
+
+func (c *Checker) checkSubroutine(subroutine *ast.SubroutineDec) {
+	c.subroutineSymTable.Reset()
+	c.subroutine = subroutine
+	if subroutine.ReturnType.Name != "void" {
+		c.checkType(subroutine.ReturnType)
+	}
+	for _, param := range subroutine.Params {
+		c.checkType(param.Type)
+		c.declare(c.subroutineSymTable, param.Name, param.Type.Name, symtable.Arg)
+	}
+	for _, varDec := range subroutine.VarDecs {
+		c.checkType(varDec.Type)
+		for _, name := range varDec.Names {
+			c.declare(c.subroutineSymTable, name, varDec.Type.Name, symtable.Var)
+		}
+	}
+	c.checkStatements(subroutine.Statements)
+}
+
+func (c *Checker) checkType(typeIdent ast.Ident) {
+	if isPrimitiveType(typeIdent.Name) {
+		return
+	}
+	if _, found := c.program.LookupClass(typeIdent.Name); !found {
+		c.reporter.Errorf(typeIdent.Position, "unknown type %q", typeIdent.Name)
+	}
+}
+
+func (c *Checker) declare(symTable *symtable.SymbolTable, name ast.Ident, entryType string, kind symtable.SymbolTableEntryKind) {
+	if symTable.KindOf(name.Name) != symtable.None {
+		c.reporter.Errorf(name.Position, "%q already declared", name.Name)
+		return
+	}
+	symTable.Define(name.Name, entryType, kind)
+}
+
+func (c *Checker) checkDeclared(name ast.Ident) {
+	if c.getSymbolTable(name.Name).KindOf(name.Name) == symtable.None {
+		c.reporter.Errorf(name.Position, "undeclared variable %q", name.Name)
+		return
+	}
+	c.checkFieldAccess(name)
+}
+
+func (c *Checker) checkFieldAccess(name ast.Ident) {
+	if c.subroutine.Kind == "function" && c.getSymbolTable(name.Name).KindOf(name.Name) == symtable.Field {
+		c.reporter.Errorf(name.Position, "field %q used in function %s.%s", name.Name, c.className, c.subroutine.Name.Name)
+	}
+}
+
+func (c *Checker) checkStatements(statements []ast.Statement) {
+	for _, statement := range statements {
+		switch s := statement.(type) {
+		case *ast.LetStatement:
+			c.checkLet(s)
+		case *ast.IfStatement:
+			c.checkConditionType(s.Condition.Pos(), c.checkExpression(s.Condition))
+			c.checkStatements(s.Then)
+			c.checkStatements(s.Else)
+		case *ast.WhileStatement:
+			c.checkConditionType(s.Condition.Pos(), c.checkExpression(s.Condition))
+			c.checkStatements(s.Body)
+		case *ast.DoStatement:
+			c.checkSubroutineCall(s.Call)
+		case *ast.ReturnStatement:
+			c.checkReturn(s)
+		}
+	}
+}
+
+func (c *Checker) checkLet(statement *ast.LetStatement) {
+	c.checkDeclared(statement.Name)
+	targetType := c.getSymbolTable(statement.Name.Name).TypeOf(statement.Name.Name)
+	if statement.Index != nil {
+		targetType = ""
+		c.checkArrayType(statement.Name)
+		c.checkIndexType(statement.Index.Pos(), c.checkExpression(statement.Index))
+	}
+	valueType := c.checkExpression(statement.Value)
+	if !isAssignable(targetType, valueType) {
+		c.typeError(statement.Value.Pos(), "cannot assign %s to %s variable %q", describeType(valueType), targetType, statement.Name.Name)
+	}
+}
+
+func (c *Checker) checkReturn(statement *ast.ReturnStatement) {
+	returnType := c.subroutine.ReturnType.Name
+	if statement.Value == nil {
+		if returnType != "void" {
+			c.typeError(statement.Position, "missing return value in subroutine returning %s", returnType)
+		}
+		return
+	}
+
+	valueType := c.checkExpression(statement.Value)
+	if returnType == "void" {
+		c.typeError(statement.Position, "void subroutine cannot return a value")
+	} else if !isAssignable(returnType, valueType) {
+		c.typeError(statement.Position, "cannot return %s from subroutine returning %s", describeType(valueType), returnType)
+	}
+}
+
+func (c *Checker) checkExpression(expression ast.Expression) string {
+	switch e := expression.(type) {
+	case *ast.BinaryExpression:
+		leftType := c.checkExpression(e.Left)
+		rightType := c.checkExpression(e.Right)
+		return c.checkOperatorTypes(e.OperatorPos, e.Operator, leftType, rightType)
+	case *ast.UnaryExpression:
+		return c.checkUnaryOperatorType(e.Position, e.Operator, c.checkExpression(e.Operand))
+	case *ast.ParenExpression:
+		return c.checkExpression(e.Inner)
+	case *ast.IntegerConstant:
+		return "int"
+	case *ast.StringConstant:
+		return "String"
+	case *ast.KeywordConstant:
+		switch e.Value {
+		case "true", "false":
+			return "boolean"
+		case "null":
+			return nullType
+		case "this":
+			if c.subroutine.Kind == "function" {
+				c.reporter.Errorf(e.Position, "this used in function %s.%s", c.className, c.subroutine.Name.Name)
+			}
+			return c.className
+		}
+	case *ast.VarRef:
+		c.checkDeclared(e.Name)
+		return c.getSymbolTable(e.Name.Name).TypeOf(e.Name.Name)
+	case *ast.ArrayAccess:
+		c.checkDeclared(e.Name)
+		c.checkArrayType(e.Name)
+		c.checkIndexType(e.Index.Pos(), c.checkExpression(e.Index))
+	case *ast.SubroutineCall:
+		return c.checkSubroutineCall(e)
+	}
+	return ""
+}
+
+func (c *Checker) checkSubroutineCall(call *ast.SubroutineCall) string {
+	var target callTarget
+	var className string
+	if call.Receiver.Name == "" {
+		target = thisCall
+		className = c.className
+	} else if symTable := c.getSymbolTable(call.Receiver.Name); symTable.KindOf(call.Receiver.Name) != symtable.None {
+		target = objectCall
+		className = symTable.TypeOf(call.Receiver.Name)
+		c.checkFieldAccess(call.Receiver)
+	} else {
+		target = classCall
+		className = call.Receiver.Name
+	}
+
+	argTypes := []string{}
+	for _, arg := range call.Args {
+		argTypes = append(argTypes, c.checkExpression(arg))
+	}
+
+	pos := call.Pos()
+	subroutineName := call.Name.Name
+	if target == objectCall && isPrimitiveType(className) {
+		c.reporter.Errorf(pos, "cannot call %s on %s value", subroutineName, className)
+		return ""
+	}
+	if _, found := c.program.LookupClass(className); !found {
+		// The unknown type of a variable is reported at its declaration.
+		if target != objectCall {
+			c.reporter.Errorf(pos, "undeclared class or variable %q", className)
+		}
+		return ""
+	}
+	subroutine, found := c.program.LookupSubroutine(className, subroutineName)
+	if !found {
+		c.reporter.Errorf(pos, "unknown subroutine %s.%s", className, subroutineName)
+		return ""
+	}
+	if len(subroutine.ParamTypes) != len(argTypes) {
+		c.reporter.Errorf(pos, "%s.%s expects %d arguments, found %d", className, subroutineName, len(subroutine.ParamTypes), len(argTypes))
+		return subroutine.ReturnType
+	}
+
+	switch {
+	case target == objectCall && subroutine.Kind != Method:
+		c.reporter.Errorf(pos, "%s.%s is not a method", className, subroutineName)
+	case target == classCall && subroutine.Kind == Method:
+		c.reporter.Errorf(pos, "method %s.%s called without an object", className, subroutineName)
+	case target == thisCall && subroutine.Kind == Method && c.subroutine.Kind == "function":
+		c.reporter.Errorf(pos, "method %s.%s called from a function", className, subroutineName)
+	}
+	for i, argType := range argTypes {
+		if !isAssignable(subroutine.ParamTypes[i], argType) {
+			c.typeError(pos, "argument %d of %s.%s: cannot use %s as %s", i+1, className, subroutineName, describeType(argType), subroutine.ParamTypes[i])
+		}
+	}
+	return subroutine.ReturnType
+}
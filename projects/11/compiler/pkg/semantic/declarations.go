@@ -0,0 +1,41 @@
+package semantic
+
+import (
+	"compiler/pkg/ast"
+	"compiler/pkg/diag"
+)
+
+func DeclareClass(classDec *ast.Class, reporter *diag.Reporter) *Class {
+	class := &Class{
+		Name:        classDec.Name.Name,
+		Subroutines: make(map[string]Subroutine),
+		Pos:         classDec.Name.Position,
+	}
+
+	for _, subroutineDec := range classDec.Subroutines {
+		subroutine := Subroutine{
+			Name:       subroutineDec.Name.Name,
+			ReturnType: subroutineDec.ReturnType.Name,
+			ParamTypes: []string{},
+			Pos:        subroutineDec.Name.Position,
+		}
+		switch subroutineDec.Kind {
+		case "constructor":
+			subroutine.Kind = Constructor
+		case "function":
+			subroutine.Kind = Function
+		case "method":
+			subroutine.Kind = Method
+		}
+		for _, param := range subroutineDec.Params {
+			subroutine.ParamTypes = append(subroutine.ParamTypes, param.Type.Name)
+		}
+
+		if existingSubroutine, found := class.Subroutines[subroutine.Name]; found {
+			reporter.Errorf(subroutine.Pos, "subroutine %s.%s already declared at %s", class.Name, subroutine.Name, existingSubroutine.Pos)
+			continue
+		}
+		class.Subroutines[subroutine.Name] = subroutine
+	}
+	return class
+}
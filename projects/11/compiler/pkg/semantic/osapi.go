@@ -0,0 +1,95 @@
+package semantic
+
+func newOSClass(name string, subroutines ...Subroutine) *Class {
+	class := &Class{
+		Name:        name,
+		Subroutines: make(map[string]Subroutine),
+		isBuiltIn:   true,
+	}
+	for _, subroutine := range subroutines {
+		class.Subroutines[subroutine.Name] = subroutine
+	}
+	return class
+}
+
+func function(name, returnType string, paramTypes ...string) Subroutine {
+	return Subroutine{Name: name, Kind: Function, ReturnType: returnType, ParamTypes: paramTypes}
+}
+
+func method(name, returnType string, paramTypes ...string) Subroutine {
+	return Subroutine{Name: name, Kind: Method, ReturnType: returnType, ParamTypes: paramTypes}
+}
+
+func constructor(name, returnType string, paramTypes ...string) Subroutine {
+	return Subroutine{Name: name, Kind: Constructor, ReturnType: returnType, ParamTypes: paramTypes}
+}
+
+func getOSClasses() []*Class {
+	return []*Class{
+		newOSClass("Math",
+			function("init", "void"),
+			function("abs", "int", "int"),
+			function("multiply", "int", "int", "int"),
+			function("divide", "int", "int", "int"),
+			function("min", "int", "int", "int"),
+			function("max", "int", "int", "int"),
+			function("sqrt", "int", "int"),
+		),
+		newOSClass("String",
+			constructor("new", "String", "int"),
+			method("dispose", "void"),
+			method("length", "int"),
+			method("charAt", "char", "int"),
+			method("setCharAt", "void", "int", "char"),
+			method("appendChar", "String", "char"),
+			method("eraseLastChar", "void"),
+			method("intValue", "int"),
+			method("setInt", "void", "int"),
+			function("backSpace", "char"),
+			function("doubleQuote", "char"),
+			function("newLine", "char"),
+		),
+		newOSClass("Array",
+			function("new", "Array", "int"),
+			method("dispose", "void"),
+		),
+		newOSClass("Output",
+			function("init", "void"),
+			function("moveCursor", "void", "int", "int"),
+			function("printChar", "void", "char"),
+			function("printString", "void", "String"),
+			function("printInt", "void", "int"),
+			function("println", "void"),
+			function("backSpace", "void"),
+		),
+		newOSClass("Screen",
+			function("init", "void"),
+			function("clearScreen", "void"),
+			function("setColor", "void", "boolean"),
+			function("drawPixel", "void", "int", "int"),
+			function("drawLine", "void", "int", "int", "int", "int"),
+			function("drawRectangle", "void", "int", "int", "int", "int"),
+			function("drawCircle", "void", "int", "int", "int"),
+		),
+		newOSClass("Keyboard",
+			function("init", "void"),
+			function("keyPressed", "char"),
+			function("readChar", "char"),
+			function("readLine", "String", "String"),
+			function("readInt", "int", "String"),
+		),
+		newOSClass("Memory",
+			function("init", "void"),
+			function("peek", "int", "int"),
+			function("poke", "void", "int", "int"),
+			function("alloc", "Array", "int"),
+			function("deAlloc", "void", "Array"),
+		),
+		newOSClass("Sys",
+			function("init", "void"),
+			function("halt", "void"),
+			function("error", "void", "int"),
+			function("wait", "void", "int"),
+		),
+	}
+}
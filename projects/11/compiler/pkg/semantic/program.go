@@ -0,0 +1,64 @@
+package semantic
+
+import (
+	"compiler/pkg/diag"
+)
+
+type SubroutineKind int
+
+const (
+	Constructor SubroutineKind = iota
+	Function
+	Method
+)
+
+type Subroutine struct {
+	Name       string
+	Kind       SubroutineKind
+	ReturnType string
+	ParamTypes []string
+	Pos        diag.Pos
+}
+
+type Class struct {
+	Name        string
+	Subroutines map[string]Subroutine
+	Pos         diag.Pos
+	isBuiltIn   bool
+}
+
+type Program struct {
+	classes map[string]*Class
+}
+
+func NewProgram() *Program {
+	p := &Program{
+		classes: make(map[string]*Class),
+	}
+	for _, class := range getOSClasses() {
+		p.classes[class.Name] = class
+	}
+	return p
+}
+
+func (p *Program) AddClass(class *Class, reporter *diag.Reporter) {
+	if existingClass, found := p.classes[class.Name]; found && !existingClass.isBuiltIn {
+		reporter.Errorf(class.Pos, "class %s already declared at %s", class.Name, existingClass.Pos)
+		return
+	}
+	p.classes[class.Name] = class
+}
+
+func (p *Program) LookupClass(name string) (*Class, bool) {
+	class, found := p.classes[name]
+	return class, found
+}
+
+func (p *Program) LookupSubroutine(className, subroutineName string) (Subroutine, bool) {
+	class, found := p.classes[className]
+	if !found {
+		return Subroutine{}, false
+	}
+	subroutine, found := class.Subroutines[subroutineName]
+	return subroutine, found
+}
@@ -0,0 +1,111 @@
+package semantic
+
+import (
+	"compiler/pkg/ast"
+	"compiler/pkg/diag"
+)
+
+const nullType = "null"
+
+func isPrimitiveType(t string) bool {
+	return t == "int" || t == "char" || t == "boolean"
+}
+
+func isNumericType(t string) bool {
+	return t == "" || t == "int" || t == "char"
+}
+
+func isAssignable(targetType, valueType string) bool {
+	if targetType == "" || valueType == "" || targetType == valueType {
+		return true
+	}
+	if isNumericType(targetType) && isNumericType(valueType) {
+		return true
+	}
+	// An Array holds raw addresses, so it converts to and from any type.
+	if targetType == "Array" || valueType == "Array" {
+		return true
+	}
+	if isPrimitiveType(targetType) || isPrimitiveType(valueType) {
+		return false
+	}
+	return valueType == nullType
+}
+
+func describeType(t string) string {
+	if t == "" {
+		return "unknown"
+	}
+	return t
+}
+
+func (c *Checker) typeError(pos diag.Pos, format string, args ...any) {
+	if c.isStrict {
+		c.reporter.Errorf(pos, "type error: "+format, args...)
+	}
+}
+
+func (c *Checker) checkOperatorTypes(pos diag.Pos, operator, leftType, rightType string) string {
+	switch operator {
+	case "+", "-", "*", "/", "<", ">":
+		if !isNumericType(leftType) || !isNumericType(rightType) {
+			c.typeError(pos, "operator %q requires int operands, found %s and %s", operator, describeType(leftType), describeType(rightType))
+		}
+		if operator == "<" || operator == ">" {
+			return "boolean"
+		}
+		return "int"
+	case "=":
+		if !isAssignable(leftType, rightType) {
+			c.typeError(pos, "cannot compare %s and %s", describeType(leftType), describeType(rightType))
+		}
+		return "boolean"
+	case "&", "|":
+		if leftType == "" {
+			leftType = rightType
+		} else if rightType == "" {
+			rightType = leftType
+		}
+		if leftType == "boolean" && rightType == "boolean" {
+			return "boolean"
+		}
+		if isNumericType(leftType) && isNumericType(rightType) {
+			return leftType
+		}
+		c.typeError(pos, "operator %q requires boolean or int operands, found %s and %s", operator, describeType(leftType), describeType(rightType))
+	}
+	return ""
+}
+
+func (c *Checker) checkUnaryOperatorType(pos diag.Pos, operator, operandType string) string {
+	if operator == "~" && operandType == "boolean" {
+		return "boolean"
+	}
+	if !isNumericType(operandType) {
+		c.typeError(pos, "operator %q cannot be applied to %s", operator, operandType)
+		return ""
+	}
+	if operandType == "" {
+		return ""
+	}
+	return "int"
+}
+
+func (c *Checker) checkConditionType(pos diag.Pos, conditionType string) {
+	if conditionType != "" && conditionType != "boolean" {
+		c.typeError(pos, "condition must be boolean, found %s", conditionType)
+	}
+}
+
+func (c *Checker) checkIndexType(pos diag.Pos, indexType string) {
+	if !isNumericType(indexType) {
+		c.typeError(pos, "array index must be int, found %s", indexType)
+	}
+}
+
+func (c *Checker) checkArrayType(name ast.Ident) {
+	varType := c.getSymbolTable(name.Name).TypeOf(name.Name)
+	if varType != "" && varType != "Array" {
+		c.typeError(name.Position, "cannot index %s variable %q", varType, name.Name)
+	}
+}
@@ -0,0 +1,76 @@
+package semantic
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"compiler/pkg/ast"
+	"compiler/pkg/diag"
+	"compiler/pkg/parser"
+	"compiler/pkg/tokenizer"
+)
+
+func TestIsAssignable(t *testing.T) {
+	tests := []struct {
+		target, value string
+		want          bool
+	}{
+		{"int", "char", true},
+		{"int", "boolean", false},
+		{"int", "Array", true},
+		{"Array", "int", true},
+		{"String", "Array", true},
+		{"Array", "Square", true},
+		{"Square", "null", true},
+		{"Square", "String", false},
+		{"boolean", "null", false},
+	}
+	for _, tt := range tests {
+		if got := isAssignable(tt.target, tt.value); got != tt.want {
+			t.Errorf("isAssignable(%q, %q) = %v, want %v", tt.target, tt.value, got, tt.want)
+		}
+	}
+}
+
+// TestStrictCoursePrograms checks that the project 11 programs pass strict
+// type checking.
+func TestStrictCoursePrograms(t *testing.T) {
+	dirs, err := filepath.Glob("../../../*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checked := 0
+	for _, dir := range dirs {
+		filePaths, _ := filepath.Glob(filepath.Join(dir, "*.jack"))
+		if len(filePaths) == 0 {
+			continue
+		}
+
+		program := NewProgram()
+		reporter := diag.NewReporter()
+		var classes []*ast.Class
+		for _, filePath := range filePaths {
+			file, err := os.Open(filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			class := parser.New(tokenizer.New(file, filePath, reporter), reporter).ParseClass()
+			file.Close()
+			program.AddClass(DeclareClass(class, reporter), reporter)
+			classes = append(classes, class)
+		}
+		for _, class := range classes {
+			checker := NewChecker(program, reporter)
+			checker.SetStrict(true)
+			checker.CheckClass(class)
+		}
+		for _, d := range reporter.Diagnostics() {
+			t.Errorf("%s: %v", filepath.Base(dir), d)
+		}
+		checked++
+	}
+	if checked == 0 {
+		t.Fatal("no course programs found")
+	}
+}
@@ -2,7 +2,8 @@ package tokenizer
 
 import (
 	"bufio"
-	"os"
+	"compiler/pkg/diag"
+	"io"
 	"strconv"
 	"strings"
 )
@@ -15,114 +16,216 @@ const (
 	Identifier
 	IntConst
 	StringConst
+	EOF
 )
 
-type Tokenizer struct {
-	tokens         []string
-	currTokenIndex int
+type Token struct {
+	Type TokenType
+	Text string
+	Pos  diag.Pos
 }
 
-func New(filename string) *Tokenizer {
-	return &Tokenizer{
-		tokens:         readTokens(filename),
-		currTokenIndex: 0,
-	}
-}
-
-func readTokens(filename string) []string {
-	fileData := readFileDatatWithoutSingleLineComments(filename)
-	fileData = removeMultiLineComments(fileData)
-
-	var tokens []string
-	var token string
-	for i := 0; i < len(fileData); i++ {
-		char := string(fileData[i])
-		if char == " " {
-			tokens = addNonEmptyToken(tokens, token)
-			token = ""
-		} else if isSymbol(char) {
-			tokens = addNonEmptyToken(tokens, token)
-			tokens = append(tokens, char)
-			token = ""
-		} else if char == "\"" {
-			tokens = addNonEmptyToken(tokens, token)
-			closingIndex := strings.Index(string(fileData[i+1:]), "\"")
-			tokens = append(tokens, fileData[i:i+2+closingIndex])
-			i += closingIndex + 1
-			token = ""
-		} else {
-			token += char
-		}
+const maxIntConst = 32767
+
+type Lexer struct {
+	reader   *bufio.Reader
+	filename string
+	reporter *diag.Reporter
+	line     int
+	col      int
+}
+
+func NewLexer(r io.Reader, filename string, reporter *diag.Reporter) *Lexer {
+	return &Lexer{
+		reader:   bufio.NewReader(r),
+		filename: filename,
+		reporter: reporter,
+		line:     1,
+		col:      1,
 	}
-	tokens = addNonEmptyToken(tokens, token)
-	return tokens
 }
 
-func readFileDatatWithoutSingleLineComments(filename string) string {
-	file, _ := os.Open(filename)
-	defer file.Close()
+func (l *Lexer) pos() diag.Pos {
+	return diag.Pos{File: l.filename, Line: l.line, Col: l.col}
+}
+
+func (l *Lexer) peek(offset int) (byte, bool) {
+	bytes, err := l.reader.Peek(offset + 1)
+	if err != nil || len(bytes) <= offset {
+		return 0, false
+	}
+	return bytes[offset], true
+}
+
+func (l *Lexer) read() byte {
+	char, _ := l.reader.ReadByte()
+	if char == '\n' {
+		l.line++
+		l.col = 1
+	} else {
+		l.col++
+	}
+	return char
+}
 
-	fileData := ""
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line, _, _ := strings.Cut(scanner.Text(), "//")
-		trimmedLine := strings.TrimSpace(line)
-		if trimmedLine != "" {
-			fileData += trimmedLine
+func (l *Lexer) skipWhitespaceAndComments() {
+	for {
+		char, ok := l.peek(0)
+		if !ok {
+			return
+		}
+		next, _ := l.peek(1)
+		switch {
+		case char == ' ' || char == '\t' || char == '\r' || char == '\n':
+			l.read()
+		case char == '/' && next == '/':
+			for char, ok := l.peek(0); ok && char != '\n'; char, ok = l.peek(0) {
+				l.read()
+			}
+		case char == '/' && next == '*':
+			pos := l.pos()
+			l.read()
+			l.read()
+			if !l.skipMultiLineComment() {
+				l.reporter.Errorf(pos, "unterminated comment")
+			}
+		default:
+			return
 		}
 	}
-	return fileData
 }
 
-func removeMultiLineComments(fileData string) string {
-	beforeCommentText, _, foundComment := strings.Cut(fileData, "/*")
-	if foundComment {
-		_, afterCommentText, _ := strings.Cut(fileData, "*/")
-		return beforeCommentText + removeMultiLineComments(afterCommentText)
+func (l *Lexer) skipMultiLineComment() bool {
+	for {
+		char, ok := l.peek(0)
+		if !ok {
+			return false
+		}
+		next, _ := l.peek(1)
+		l.read()
+		if char == '*' && next == '/' {
+			l.read()
+			return true
+		}
 	}
-	return beforeCommentText
 }
 
-func addNonEmptyToken(tokens []string, token string) []string {
-	trimmedToken := strings.TrimSpace(token)
-	if trimmedToken != "" {
-		return append(tokens, trimmedToken)
+func (l *Lexer) Next() Token {
+	for {
+		l.skipWhitespaceAndComments()
+		pos := l.pos()
+		char, ok := l.peek(0)
+		if !ok {
+			return Token{Type: EOF, Pos: pos}
+		}
+
+		switch {
+		case char == '"':
+			return Token{Type: StringConst, Text: l.readStringConst(pos), Pos: pos}
+		case isDigit(char):
+			return Token{Type: IntConst, Text: l.readIntConst(pos), Pos: pos}
+		case isLetter(char):
+			text := l.readWhile(isIdentifierChar)
+			if isKeyword(text) {
+				return Token{Type: Keyword, Text: text, Pos: pos}
+			}
+			return Token{Type: Identifier, Text: text, Pos: pos}
+		case isSymbol(string(char)):
+			l.read()
+			return Token{Type: Symbol, Text: string(char), Pos: pos}
+		default:
+			l.read()
+			l.reporter.Errorf(pos, "illegal character %q", char)
+		}
 	}
-	return tokens
 }
 
-func (t *Tokenizer) HasMoreTokens() bool {
-	return t.currTokenIndex < len(t.tokens)-1
+func (l *Lexer) readStringConst(pos diag.Pos) string {
+	l.read()
+	var sb strings.Builder
+	for {
+		char, ok := l.peek(0)
+		switch {
+		case !ok:
+			l.reporter.Errorf(pos, "unterminated string constant")
+			return sb.String()
+		case char == '\n':
+			l.reporter.Errorf(pos, "newline in string constant")
+			return sb.String()
+		case char == '"':
+			l.read()
+			return sb.String()
+		}
+		sb.WriteByte(l.read())
+	}
 }
 
-func (t *Tokenizer) Advance() {
-	t.currTokenIndex++
+func (l *Lexer) readIntConst(pos diag.Pos) string {
+	text := l.readWhile(isDigit)
+	if char, ok := l.peek(0); ok && isLetter(char) {
+		text += l.readWhile(isIdentifierChar)
+		l.reporter.Errorf(pos, "invalid integer constant %q", text)
+		return text
+	}
+	if value, err := strconv.Atoi(text); err != nil || value > maxIntConst {
+		l.reporter.Errorf(pos, "integer constant %s exceeds %d", text, maxIntConst)
+	}
+	return text
 }
 
-func (t *Tokenizer) TokenType() TokenType {
-	currToken := t.tokens[t.currTokenIndex]
-	if isSymbol(currToken) {
-		return Symbol
-	} else if isKeyword(currToken) {
-		return Keyword
-	} else if isIntConst(currToken) {
-		return IntConst
-	} else if isStringConst(currToken) {
-		return StringConst
+func (l *Lexer) readWhile(predicate func(byte) bool) string {
+	var sb strings.Builder
+	for char, ok := l.peek(0); ok && predicate(char); char, ok = l.peek(0) {
+		sb.WriteByte(l.read())
 	}
-	return Identifier
+	return sb.String()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+func isIdentifierChar(c byte) bool {
+	return isLetter(c) || isDigit(c)
+}
+
+type Tokenizer struct {
+	lexer     *Lexer
+	currToken Token
 }
 
-func isIntConst(s string) bool {
-	_, err := strconv.Atoi(s)
-	return err == nil
+func New(r io.Reader, filename string, reporter *diag.Reporter) *Tokenizer {
+	lexer := NewLexer(r, filename, reporter)
+	return &Tokenizer{
+		lexer:     lexer,
+		currToken: lexer.Next(),
+	}
 }
 
-func isStringConst(s string) bool {
-	if len(s) < 2 {
-		return false
+func (t *Tokenizer) Advance() {
+	if !t.AtEnd() {
+		t.currToken = t.lexer.Next()
 	}
-	return string(s[0]) == "\"" && string(s[len(s)-1]) == "\""
+}
+
+func (t *Tokenizer) AtEnd() bool {
+	return t.currToken.Type == EOF
+}
+
+func (t *Tokenizer) Token() Token {
+	return t.currToken
+}
+
+func (t *Tokenizer) Pos() diag.Pos {
+	return t.currToken.Pos
+}
+
+func (t *Tokenizer) TokenType() TokenType {
+	return t.currToken.Type
 }
 
 func isKeyword(s string) bool {
@@ -134,29 +237,25 @@ func isKeyword(s string) bool {
 	return false
 }
 
-func (t *Tokenizer) getToken() string {
-	return t.tokens[t.currTokenIndex]
-}
-
 func (t *Tokenizer) KeyWord() string {
-	return t.getToken()
+	return t.currToken.Text
 }
 
 func (t *Tokenizer) Symbol() string {
-	return t.getToken()
+	return t.currToken.Text
 }
 
 func (t *Tokenizer) Identifier() string {
-	return t.getToken()
+	return t.currToken.Text
 }
 
 func (t *Tokenizer) IntVal() int {
-	val, _ := strconv.Atoi(t.getToken())
+	val, _ := strconv.Atoi(t.currToken.Text)
 	return val
 }
 
 func (t *Tokenizer) StringVal() string {
-	return t.getToken()[1 : len(t.getToken())-1]
+	return t.currToken.Text
 }
 
 func getKeywords() []string {
@@ -1,7 +1,7 @@
 package vmwriter
 
 import (
-	"os"
+	"io"
 	"strconv"
 )
 
@@ -33,21 +33,21 @@ const (
 )
 
 type VMWriter struct {
-	outputFile *os.File
+	output io.StringWriter
 }
 
-func New(outputFile *os.File) *VMWriter {
+func New(output io.StringWriter) *VMWriter {
 	return &VMWriter{
-		outputFile: outputFile,
+		output: output,
 	}
 }
 
 func (w *VMWriter) WritePush(segment MemorySegment, index int) {
-	w.outputFile.WriteString("push " + getSegmentAlias(segment) + " " + strconv.Itoa(index) + "\n")
+	w.output.WriteString("push " + getSegmentAlias(segment) + " " + strconv.Itoa(index) + "\n")
 }
 
 func (w *VMWriter) WritePop(segment MemorySegment, index int) {
-	w.outputFile.WriteString("pop " + getSegmentAlias(segment) + " " + strconv.Itoa(index) + "\n")
+	w.output.WriteString("pop " + getSegmentAlias(segment) + " " + strconv.Itoa(index) + "\n")
 }
 
 func getSegmentAlias(segment MemorySegment) string {
@@ -97,29 +97,29 @@ func (w *VMWriter) WriteArithmetic(command ArithmeticCommand) {
 	default:
 		panic("Undefined alias for arithemtic command")
 	}
-	w.outputFile.WriteString(instruction + "\n")
+	w.output.WriteString(instruction + "\n")
 }
 
 func (w *VMWriter) WriteLabel(label string) {
-	w.outputFile.WriteString("label " + label + "\n")
+	w.output.WriteString("label " + label + "\n")
 }
 
 func (w *VMWriter) WriteGoto(label string) {
-	w.outputFile.WriteString("goto " + label + "\n")
+	w.output.WriteString("goto " + label + "\n")
 }
 
 func (w *VMWriter) WriteIf(label string) {
-	w.outputFile.WriteString("if-goto " + label + "\n")
+	w.output.WriteString("if-goto " + label + "\n")
 }
 
 func (w *VMWriter) WriteCall(name string, nArgs int) {
-	w.outputFile.WriteString("call " + name + " " + strconv.Itoa(nArgs) + "\n")
+	w.output.WriteString("call " + name + " " + strconv.Itoa(nArgs) + "\n")
 }
 
 func (w *VMWriter) WriteFunction(name string, nArgs int) {
-	w.outputFile.WriteString("function " + name + " " + strconv.Itoa(nArgs) + "\n")
+	w.output.WriteString("function " + name + " " + strconv.Itoa(nArgs) + "\n")
 }
 
 func (w *VMWriter) WriteReturn() {
-	w.outputFile.WriteString("return\n")
+	w.output.WriteString("return\n")
 }